@@ -10,6 +10,7 @@ import (
 
 	"github.com/jessevdk/go-flags"
 	"github.com/komodorio/komoplane/pkg/backend"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -21,12 +22,40 @@ var (
 )
 
 type options struct {
-	Version    bool   `long:"version" description:"Show tool version"`
-	Verbose    bool   `short:"v" long:"verbose" description:"Show verbose debug information"`
-	NoTracking bool   `long:"no-analytics" description:"Disable user analytics (Heap, DataDog etc.)"`
-	BindHost   string `long:"bind" description:"Host binding to start server (default: localhost)"` // default should be printed but not assigned as the precedence: flag > env > default
-	Port       uint   `short:"p" long:"port" description:"Port to start server on" default:"8090"`
-	Namespace  string `short:"n" long:"namespace" description:"Namespace for operations"`
+	Version     bool        `long:"version" description:"Show tool version"`
+	Verbose     bool        `short:"v" long:"verbose" description:"Show verbose debug information"`
+	NoTracking  bool        `long:"no-analytics" description:"Disable user analytics (Heap, DataDog etc.)"`
+	BindHost    string      `long:"bind" description:"Host binding to start server (default: localhost)"` // default should be printed but not assigned as the precedence: flag > env > default
+	Port        uint        `short:"p" long:"port" description:"Port to start server on" default:"8090"`
+	Namespace   string      `short:"n" long:"namespace" description:"Namespace for operations"`
+	Actions     bool        `long:"enable-actions" description:"Allow pausing, resuming and reconciling resources, otherwise komoplane is read-only"`
+	Contexts    []string    `long:"context" description:"Kubeconfig context to serve, can be repeated for multiple clusters. The first one is the default"`
+	AllContexts bool        `long:"all-contexts" description:"Serve all contexts from kubeconfig, current context is the default"`
+	Auth        authOptions `group:"Authentication"`
+	TLS         tlsOptions  `group:"TLS"`
+}
+
+type tlsOptions struct {
+	Cert     string `long:"tls-cert" env:"KP_TLS_CERT" description:"Certificate file to serve HTTPS, reloaded when changed"`
+	Key      string `long:"tls-key" env:"KP_TLS_KEY" description:"Private key file for the certificate"`
+	ClientCA string `long:"tls-client-ca" env:"KP_TLS_CLIENT_CA" description:"CA file to require and verify client certificates with (mTLS)"`
+}
+
+type authOptions struct {
+	Mode          string   `long:"auth" env:"KP_AUTH_MODE" description:"Authentication mode" choice:"none" choice:"token" choice:"basic" choice:"oidc" choice:"header" default:"none"`
+	Token         string   `long:"auth-token" env:"KP_AUTH_TOKEN" description:"Static token for token mode, accepted as Bearer token or ?token= URL parameter"`
+	BasicFile     string   `long:"auth-basic-file" env:"KP_AUTH_BASIC_FILE" description:"File with user:bcrypt-hash[:group1,group2] lines for basic mode"`
+	HeaderUser    string   `long:"auth-header-user" env:"KP_AUTH_HEADER_USER" description:"Trusted header with user name for header mode" default:"X-Forwarded-User"`
+	HeaderGroups  string   `long:"auth-header-groups" env:"KP_AUTH_HEADER_GROUPS" description:"Trusted header with comma-separated groups for header mode" default:"X-Forwarded-Groups"`
+	OIDCIssuer    string   `long:"oidc-issuer" env:"KP_OIDC_ISSUER" description:"OIDC issuer URL"`
+	OIDCClientID  string   `long:"oidc-client-id" env:"KP_OIDC_CLIENT_ID" description:"OIDC client ID"`
+	OIDCSecret    string   `long:"oidc-client-secret" env:"KP_OIDC_CLIENT_SECRET" description:"OIDC client secret"`
+	OIDCRedirect  string   `long:"oidc-redirect-url" env:"KP_OIDC_REDIRECT_URL" description:"OIDC redirect URL, that is <komoplane URL>/auth/callback"`
+	OIDCScopes    []string `long:"oidc-scope" env:"KP_OIDC_SCOPES" env-delim:"," description:"OIDC scopes to request besides openid (default: profile, email, groups)"`
+	OIDCGroups    string   `long:"oidc-groups-claim" env:"KP_OIDC_GROUPS_CLAIM" description:"ID token claim with user groups" default:"groups"`
+	SessionSecret string   `long:"session-secret" env:"KP_SESSION_SECRET" description:"Key to sign session cookies, random by default so sessions end with restart"`
+	MetricsToken  string   `long:"metrics-token" env:"KP_METRICS_TOKEN" description:"Bearer token required to scrape /metrics, which is open otherwise"`
+	Impersonate   bool     `long:"impersonate" env:"KP_IMPERSONATE" description:"Read resources on behalf of the authenticated user, so that their RBAC permissions apply, not supported with token auth"`
 }
 
 func main() {
@@ -45,14 +74,37 @@ func main() {
 	}
 
 	opts.Verbose = opts.Verbose || os.Getenv("DEBUG") != "" || os.Getenv("CGO_CFLAGS") != ""
+	opts.Actions = opts.Actions || os.Getenv("KP_ENABLE_ACTIONS") == "true"
 	setupLogging(opts.Verbose)
 
 	server := backend.Server{
-		Version:    version,
-		Namespace:  opts.Namespace,
-		Address:    fmt.Sprintf("%s:%d", opts.BindHost, opts.Port),
-		Debug:      opts.Verbose,
-		NoTracking: opts.NoTracking,
+		Version:       version,
+		Namespace:     opts.Namespace,
+		Address:       fmt.Sprintf("%s:%d", opts.BindHost, opts.Port),
+		Debug:         opts.Verbose,
+		NoTracking:    opts.NoTracking,
+		EnableActions: opts.Actions,
+		Contexts:      opts.Contexts,
+		AllContexts:   opts.AllContexts,
+		Auth: auth.Config{
+			Mode:             opts.Auth.Mode,
+			Token:            opts.Auth.Token,
+			BasicFile:        opts.Auth.BasicFile,
+			HeaderUser:       opts.Auth.HeaderUser,
+			HeaderGroups:     opts.Auth.HeaderGroups,
+			OIDCIssuer:       opts.Auth.OIDCIssuer,
+			OIDCClientID:     opts.Auth.OIDCClientID,
+			OIDCClientSecret: opts.Auth.OIDCSecret,
+			OIDCRedirectURL:  opts.Auth.OIDCRedirect,
+			OIDCScopes:       opts.Auth.OIDCScopes,
+			OIDCGroupsClaim:  opts.Auth.OIDCGroups,
+			SessionSecret:    opts.Auth.SessionSecret,
+			MetricsToken:     opts.Auth.MetricsToken,
+		},
+		Impersonate: opts.Auth.Impersonate,
+		TLSCert:     opts.TLS.Cert,
+		TLSKey:      opts.TLS.Key,
+		TLSClientCA: opts.TLS.ClientCA,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
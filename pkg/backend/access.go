@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	typedauthv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const accessCacheTTL = time.Minute
+
+// accessChecker tells if the viewer may list objects, because tracked lists come from cache filled by komoplane's own account
+type accessChecker struct {
+	reviews typedauthv1.SubjectAccessReviewInterface
+
+	mx    sync.Mutex
+	cache map[string]accessResult
+}
+
+type accessResult struct {
+	allowed bool
+	expires time.Time
+}
+
+func newAccessChecker(reviews typedauthv1.SubjectAccessReviewInterface) *accessChecker {
+	return &accessChecker{
+		reviews: reviews,
+		cache:   map[string]accessResult{},
+	}
+}
+
+func (a *accessChecker) canList(ctx context.Context, user *auth.User, gvr schema.GroupVersionResource, namespace string) bool {
+	key := strings.Join([]string{user.Name, strings.Join(user.Groups, ","), gvr.Group, gvr.Resource, namespace}, "|")
+
+	a.mx.Lock()
+	cached, found := a.cache[key]
+	a.mx.Unlock()
+	if found && time.Now().Before(cached.expires) {
+		return cached.allowed
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Name,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     gvr.Group,
+				Resource:  gvr.Resource,
+			},
+		},
+	}
+
+	allowed := false
+	res, err := a.reviews.Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		log.Warnf("Failed to review access of %s to %s, denying: %v", user.Name, gvr.GroupResource(), err)
+	} else {
+		allowed = res.Status.Allowed
+	}
+
+	a.mx.Lock()
+	a.cache[key] = accessResult{allowed: allowed, expires: time.Now().Add(accessCacheTTL)}
+	a.mx.Unlock()
+
+	return allowed
+}
+
+// filter drops the objects that user is not allowed to list, returning the kinds that were dropped
+func (a *accessChecker) filter(ctx context.Context, user *auth.User, items []unstructured.Unstructured,
+	resourceOf func(schema.GroupKind) (schema.GroupVersionResource, bool)) ([]unstructured.Unstructured, []string) {
+	res := make([]unstructured.Unstructured, 0, len(items))
+	forbiddenKinds := map[string]bool{}
+	for _, item := range items {
+		gk := item.GroupVersionKind().GroupKind()
+		gvr, found := resourceOf(gk)
+		if !found || !a.canList(ctx, user, gvr, item.GetNamespace()) {
+			forbiddenKinds[gk.String()] = true
+			continue
+		}
+		res = append(res, item)
+	}
+
+	kinds := make([]string, 0, len(forbiddenKinds))
+	for kind := range forbiddenKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return res, kinds
+}
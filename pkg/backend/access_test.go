@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAccessChecker_Filter(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	reviews := 0
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		// devs may list buckets in dev namespace only
+		review.Status.Allowed = review.Spec.Groups[0] == "devs" && attrs.Resource == "buckets" && attrs.Namespace == "dev"
+		return true, review, nil
+	})
+
+	resources := func(gk schema.GroupKind) (schema.GroupVersionResource, bool) {
+		if gk.Kind == "Bucket" {
+			return schema.GroupVersionResource{Group: gk.Group, Version: "v1", Resource: "buckets"}, true
+		}
+		return schema.GroupVersionResource{}, false
+	}
+
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "dev", "bucket-a", 0, ""),
+		testObject("Bucket", "dev", "bucket-b", 0, ""),
+		testObject("Bucket", "prod", "bucket-c", 0, ""),
+		testObject("Queue", "dev", "queue-a", 0, ""),
+	}
+
+	checker := newAccessChecker(clientset.AuthorizationV1().SubjectAccessReviews())
+	user := &auth.User{Name: "alice", Groups: []string{"devs"}}
+
+	res, forbiddenKinds := checker.filter(context.Background(), user, items, resources)
+	assert.Equal(t, []string{"bucket-a", "bucket-b"}, names(&unstructured.UnstructuredList{Items: res}))
+	assert.Equal(t, []string{"Bucket.example.org", "Queue.example.org"}, forbiddenKinds)
+	assert.Equal(t, 2, reviews, "reviews are cached per kind and namespace")
+
+	_, _ = checker.filter(context.Background(), user, items, resources)
+	assert.Equal(t, 2, reviews)
+}
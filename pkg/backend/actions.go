@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// annotationReconcileRequested has no meaning for Crossplane, changing it just triggers the reconciliation
+const annotationReconcileRequested = "komoplane.komodor.com/reconcile-requested-at"
+
+type resourceAction struct {
+	annotations map[string]interface{} // nil value removes the annotation
+	reason      string
+	message     string
+}
+
+var resourceActions = map[string]func() resourceAction{
+	"pause": func() resourceAction {
+		return resourceAction{
+			annotations: map[string]interface{}{meta.AnnotationKeyReconciliationPaused: "true"},
+			reason:      "ReconciliationPaused",
+			message:     "Reconciliation paused via komoplane",
+		}
+	},
+	"resume": func() resourceAction {
+		return resourceAction{
+			annotations: map[string]interface{}{meta.AnnotationKeyReconciliationPaused: nil},
+			reason:      "ReconciliationResumed",
+			message:     "Reconciliation resumed via komoplane",
+		}
+	},
+	"reconcile": func() resourceAction {
+		return resourceAction{
+			annotations: map[string]interface{}{annotationReconcileRequested: time.Now().UTC().Format(time.RFC3339)},
+			reason:      "ReconciliationRequested",
+			message:     "Reconciliation requested via komoplane",
+		}
+	},
+}
+
+// messageBy names the user in the event, for the audit trail
+func (a resourceAction) messageBy(user *auth.User) string {
+	if user == nil {
+		return a.message
+	}
+	return a.message + " by " + user.Name
+}
+
+// postAction makes the handler for the route group of the class, so only the objects of that class can be changed
+func postAction(class tracker.Class) func(*Controller, echo.Context) error {
+	return func(c *Controller, ec echo.Context) error {
+		return c.PostAction(ec, class)
+	}
+}
+
+// PostAction pauses, resumes or forces reconciliation of claim, XR or MR, recording it as an event on the object
+func (c *Controller) PostAction(ec echo.Context, class tracker.Class) error {
+	if !c.StatusInfo.ActionsEnabled {
+		return echo.NewHTTPError(http.StatusForbidden, "komoplane runs in read-only mode, start it with --enable-actions to allow changes")
+	}
+
+	getAction, found := resourceActions[ec.Param("action")]
+	if !found {
+		return echo.NewHTTPError(http.StatusBadRequest, "unsupported action: "+ec.Param("action"))
+	}
+	action := getAction()
+
+	gvk := schema.GroupVersionKind{
+		Group:   ec.Param("group"),
+		Version: ec.Param("version"),
+		Kind:    ec.Param("kind"),
+	}
+	ref := v12.ObjectReference{
+		Name:      ec.Param("name"),
+		Namespace: ec.Param("namespace"),
+	}
+	ref.SetGroupVersionKind(gvk)
+
+	if actual := c.Tracker.ClassOf(gvk.GroupKind()); actual != class {
+		return echo.NewHTTPError(http.StatusBadRequest, gvk.GroupKind().String()+" is not a known "+string(class)+" kind")
+	}
+
+	if err := c.inScope(ref.Namespace); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": action.annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	obj := uxres.New()
+	err = c.CRDs.Patch(c.reqCtx(ec), obj, &ref, patch)
+	if err != nil {
+		return err
+	}
+
+	ref.UID = obj.GetUID()
+	ref.ResourceVersion = obj.GetResourceVersion()
+	// recorded by komoplane itself, as viewers are not expected to have permission to create events
+	err = c.Events.Create(c.ctx, &ref, v12.EventTypeNormal, action.reason, action.messageBy(auth.UserFrom(ec)))
+	if err != nil {
+		log.Warnf("Failed to record event for %s %s: %v", ref.Kind, ref.Name, err)
+	}
+
+	return ec.JSONPretty(http.StatusOK, obj, "  ")
+}
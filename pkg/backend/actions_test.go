@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAction_UntrackedKind(t *testing.T) {
+	ec := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	ec.SetParamNames("group", "version", "kind", "namespace", "name", "action")
+	ec.SetParamValues("", "v1", "Secret", "crossplane-system", "aws-creds", "pause")
+
+	c := &Controller{StatusInfo: &StatusInfo{ActionsEnabled: true}, Tracker: &tracker.Tracker{}}
+	err := c.PostAction(ec, tracker.ClassManaged)
+
+	httpErr := &echo.HTTPError{}
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
+
+func TestResourceAction_MessageBy(t *testing.T) {
+	action := resourceActions["pause"]()
+	assert.Equal(t, "Reconciliation paused via komoplane by alice", action.messageBy(&auth.User{Name: "alice"}))
+	assert.Equal(t, "Reconciliation paused via komoplane", action.messageBy(nil), "no auth configured")
+}
@@ -1,23 +1,33 @@
 package backend
 
 import (
+	"errors"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
 	"github.com/komodorio/komoplane/pkg/frontend"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	log "github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 )
 
-func NewRouter(data *Controller, debug bool) *echo.Echo {
+// headerRequestedBy is how scripts and CLI tools make changes, browsers can't send it cross-site without CORS preflight
+const headerRequestedBy = "X-Requested-By"
+
+func NewRouter(clusters *Clusters, authn auth.Authenticator, metricsToken string, debug bool) *echo.Echo {
 	api := echo.New()
 	api.Debug = debug
 	if !debug {
 		api.Use(middleware.Recover())
 	}
 
-	configureRoutes(data, api)
+	api.Pre(clusterPrefix)
+	configureMetrics(clusters, api, metricsToken)
+	configureRoutes(clusters, api)
 	configureStatic(api)
 
 	api.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
@@ -38,12 +48,16 @@ func NewRouter(data *Controller, debug bool) *echo.Echo {
 	}))
 
 	api.Use(errSet500)
+	api.Use(k8sErrStatus)
 	api.Use(slowness)
+	api.Use(sameOrigin)
 
 	if os.Getenv("KP_CORS_OFF") != "" {
 		api.Use(devNoCORS)
 	}
 
+	auth.Configure(api, authn)
+
 	return api
 }
 
@@ -64,6 +78,33 @@ func slowness(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// sameOrigin refuses changes requested by pages of other sites, as browsers send the cached credentials along
+func sameOrigin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+
+		if req.Header.Get(headerRequestedBy) != "" {
+			return next(c)
+		}
+
+		switch req.Header.Get("Sec-Fetch-Site") {
+		case "same-origin", "none":
+			return next(c)
+		case "": // older browsers only send the origin
+			origin, err := url.Parse(req.Header.Get(echo.HeaderOrigin))
+			if err == nil && origin.Host != "" && origin.Host == req.Host {
+				return next(c)
+			}
+		}
+
+		return echo.NewHTTPError(http.StatusForbidden, "cross-site request refused, set "+headerRequestedBy+" header when calling the API from scripts")
+	}
+}
+
 func devNoCORS(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		c.Response().Header().Set(echo.HeaderAccessControlAllowOrigin, "*")
@@ -82,48 +123,90 @@ func errSet500(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-func configureRoutes(data *Controller, eng *echo.Echo) {
-	eng.GET("/status", func(c echo.Context) error {
+// k8sErrStatus passes the status of failed Kubernetes call to the client, like 403 for a viewer lacking RBAC permissions
+func k8sErrStatus(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+
+		var status k8sErrors.APIStatus
+		if errors.As(err, &status) && status.Status().Code >= http.StatusBadRequest {
+			return echo.NewHTTPError(int(status.Status().Code), status.Status().Message).SetInternal(err)
+		}
+		return err
+	}
+}
+
+func configureRoutes(clusters *Clusters, eng *echo.Echo) {
+	h := clusters.Handle
+
+	eng.GET("/status", h(func(data *Controller, c echo.Context) error {
 		return c.JSONPretty(http.StatusOK, data.GetStatus(), "  ")
-	})
+	}))
 
-	// TODO needed? then implement
-	eng.GET("/api-docs", func(c echo.Context) error { // https://github.com/OAI/OpenAPI-Specification/search?q=api-docs
-		return c.Redirect(http.StatusFound, "static/api-docs.html")
-	})
+	configureAPIDocs(eng, clusters.status.CurVer)
 
 	api := eng.Group("/api")
-	api.GET("/events/:name", data.GetEvents)
-	api.GET("/events/:namespace/:name", data.GetEvents)
+	api.GET("/clusters", clusters.GetClusters)
+	api.GET("/events/:name", h((*Controller).GetEvents))
+	api.GET("/events/:namespace/:name", h((*Controller).GetEvents))
+	api.GET("/stream", h((*Controller).GetStream))
 
 	rels := api.Group("/providers")
-	rels.GET("", data.GetProviders)
-	rels.GET("/:name", data.GetProvider)
-	rels.GET("/:name/events", data.GetProviderEvents)
-	rels.GET("/:name/configs", data.GetProviderConfigs)
+	rels.GET("", h((*Controller).GetProviders))
+	rels.GET("/:name", h((*Controller).GetProvider))
+	rels.GET("/:name/events", h((*Controller).GetProviderEvents))
+	rels.GET("/:name/configs", h((*Controller).GetProviderConfigs))
+	rels.GET("/:name/configs/:config/resources", h((*Controller).GetProviderResources))
+	rels.GET("/:name/resources", h((*Controller).GetProviderResources))
+	rels.GET("/:name/package", h((*Controller).GetProviderPackage))
+
+	api.GET("/providerconfigs", h((*Controller).GetProviderConfigsInspect))
+
+	functions := api.Group("/functions")
+	functions.GET("", h((*Controller).GetFunctions))
+	functions.GET("/:name", h((*Controller).GetFunction))
+
+	configurations := api.Group("/configurations")
+	configurations.GET("", h((*Controller).GetConfigurations))
+	configurations.GET("/:name", h((*Controller).GetConfiguration))
+
+	api.GET("/dependencies", h((*Controller).GetDependencies))
+	api.GET("/diagnostics/secrets", h((*Controller).GetSecretDiagnostics))
+	api.GET("/diagnostics/deletions", h((*Controller).GetDeletions))
+	api.GET("/health-report", h((*Controller).GetHealthReport))
+
+	graphs := api.Group("/graph")
+	graphs.GET("/:group/:version/:kind/:name", h((*Controller).GetGraph))
+	graphs.GET("/:group/:version/:kind/:namespace/:name", h((*Controller).GetGraph))
 
 	claims := api.Group("/claims")
-	claims.GET("", data.GetClaims)
-	claims.GET("/:group/:version/:kind/:namespace/:name", data.GetClaim)
+	claims.GET("", h((*Controller).GetClaims))
+	claims.GET("/:group/:version/:kind/:namespace/:name", h((*Controller).GetClaim))
+	claims.POST("/:group/:version/:kind/:namespace/:name/:action", h(postAction(tracker.ClassClaim)))
 
 	managed := api.Group("/managed")
-	managed.GET("", data.GetManageds)
-	managed.GET("/:group/:version/:kind/:name", data.GetManaged)
-	managed.GET("/:group/:version/:kind/:namespace/:name", data.GetManagedNamespaced)
+	managed.GET("", h((*Controller).GetManageds))
+	managed.GET("/:group/:version/:kind/:name", h((*Controller).GetManaged))
+	managed.GET("/:group/:version/:kind/:namespace/:name", h((*Controller).GetManagedNamespaced))
+	managed.POST("/:group/:version/:kind/:name/:action", h(postAction(tracker.ClassManaged)))
+	managed.POST("/:group/:version/:kind/:namespace/:name/:action", h(postAction(tracker.ClassManaged)))
 
 	composite := api.Group("/composite")
-	composite.GET("", data.GetComposites)
-	composite.GET("/:group/:version/:kind/:name", data.GetComposite)
-	composite.GET("/:group/:version/:kind/:namespace/:name", data.GetCompositeNamespaced)
+	composite.GET("", h((*Controller).GetComposites))
+	composite.GET("/:group/:version/:kind/:name", h((*Controller).GetComposite))
+	composite.GET("/:group/:version/:kind/:namespace/:name", h((*Controller).GetCompositeNamespaced))
+	composite.POST("/:group/:version/:kind/:name/:action", h(postAction(tracker.ClassComposite)))
+	composite.POST("/:group/:version/:kind/:namespace/:name/:action", h(postAction(tracker.ClassComposite)))
 
 	compositions := api.Group("/compositions")
-	compositions.GET("", data.GetCompositions)
+	compositions.GET("", h((*Controller).GetCompositions))
 
 	composition := api.Group("/composition")
-	composition.GET("/:name", data.GetComposition)
+	composition.GET("/:name", h((*Controller).GetComposition))
+	composition.GET("/:name/pipeline", h((*Controller).GetCompositionPipeline))
 
 	xrds := api.Group("/xrds")
-	xrds.GET("", data.GetXRDs)
+	xrds.GET("", h((*Controller).GetXRDs))
 }
 
 func configureStatic(api *echo.Echo) {
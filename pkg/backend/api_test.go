@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameOrigin(t *testing.T) {
+	eng := echo.New()
+	eng.Use(sameOrigin)
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	eng.GET("/api/claims", ok)
+	eng.POST("/api/claims/:action", ok)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{"reading", http.MethodGet, map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusOK},
+		{"same origin", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://komoplane:8090"}, http.StatusOK},
+		{"older browser", http.MethodPost, map[string]string{"Origin": "http://komoplane:8090"}, http.StatusOK},
+		{"script", http.MethodPost, map[string]string{headerRequestedBy: "curl"}, http.StatusOK},
+		{"cross site form", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "http://evil.example.com"}, http.StatusForbidden},
+		{"same site is not enough", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-site", "Origin": "http://komoplane:8090"}, http.StatusForbidden},
+		{"older browser cross site", http.MethodPost, map[string]string{"Origin": "http://evil.example.com"}, http.StatusForbidden},
+		{"no proof", http.MethodPost, nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/api/claims"
+			if tt.method == http.MethodPost {
+				path += "/pause"
+			}
+			req := httptest.NewRequest(tt.method, "http://komoplane:8090"+path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			eng.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ModeNone   = "none"
+	ModeToken  = "token"
+	ModeBasic  = "basic"
+	ModeOIDC   = "oidc"
+	ModeHeader = "header"
+
+	contextKeyUser = "user"
+)
+
+// User is the identity of the viewer, as reported by any of auth modes
+type User struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Config has the settings for all auth modes, only those of the chosen Mode are used
+type Config struct {
+	Mode string
+
+	Token string // for token mode
+
+	BasicFile string // htpasswd-like file with bcrypt hashes, for basic mode
+
+	HeaderUser   string // for header mode, like X-Forwarded-User of oauth2-proxy
+	HeaderGroups string
+
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCGroupsClaim  string
+
+	SessionSecret string // random if empty, then sessions won't survive restart
+	SessionTTL    time.Duration
+
+	MetricsToken string // Bearer token for /metrics, which is open without it, so Prometheus can scrape in any mode
+}
+
+// Authenticator checks credentials of the request in one of the supported ways
+type Authenticator interface {
+	// Authenticate returns nil user without error when there are no credentials in request
+	Authenticate(ec echo.Context) (*User, error)
+	// Challenge responds to the request that has no valid credentials
+	Challenge(ec echo.Context) error
+}
+
+// RouteProvider is implemented by authenticators that need their own endpoints, like login callback
+type RouteProvider interface {
+	Routes(g *echo.Group)
+}
+
+func New(ctx context.Context, cfg Config) (Authenticator, error) {
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = 12 * time.Hour
+	}
+
+	switch cfg.Mode {
+	case "", ModeNone:
+		return nil, nil
+	case ModeToken:
+		return newTokenAuth(cfg)
+	case ModeBasic:
+		return newBasicAuth(cfg)
+	case ModeHeader:
+		return newHeaderAuth(cfg), nil
+	case ModeOIDC:
+		return newOIDCAuth(ctx, cfg)
+	default:
+		return nil, errors.Errorf("unsupported auth mode: %s", cfg.Mode)
+	}
+}
+
+// Configure protects all the routes of server with authenticator, except status, metrics and its own endpoints under /auth
+func Configure(eng *echo.Echo, authn Authenticator) {
+	g := eng.Group("/auth")
+	g.GET("/user", func(ec echo.Context) error {
+		return ec.JSONPretty(http.StatusOK, UserFrom(ec), "  ")
+	})
+
+	if authn == nil {
+		return
+	}
+
+	if rp, ok := authn.(RouteProvider); ok {
+		rp.Routes(g)
+	}
+
+	eng.Use(middleware(authn))
+}
+
+func middleware(authn Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ec echo.Context) error {
+			path := ec.Request().URL.Path
+			// status is used for health probes, metrics are scraped with own token
+			if path == "/status" || path == "/metrics" || strings.HasPrefix(path, "/auth/") && path != "/auth/user" {
+				return next(ec)
+			}
+
+			user, err := authn.Authenticate(ec)
+			if err != nil {
+				log.Debugf("Failed to authenticate: %v", err)
+			}
+
+			if user == nil {
+				return authn.Challenge(ec)
+			}
+
+			ec.Set(contextKeyUser, user)
+			return next(ec)
+		}
+	}
+}
+
+// UserFrom returns the user of authenticated request, nil if auth is off
+func UserFrom(ec echo.Context) *User {
+	user, _ := ec.Get(contextKeyUser).(*User)
+	return user
+}
+
+// wantsJSON tells API calls from browser navigation, the latter get redirected to login
+func wantsJSON(ec echo.Context) bool {
+	req := ec.Request()
+	return strings.HasPrefix(req.URL.Path, "/api/") || req.URL.Path == "/status" ||
+		!strings.Contains(req.Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
+
+func unauthorized() error {
+	return echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+}
+
+// BearerToken protects a single route with static token, nothing is checked if the token is empty
+func BearerToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ec echo.Context) error {
+			if token == "" {
+				return next(ec)
+			}
+
+			given := strings.TrimPrefix(ec.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				ec.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return unauthorized()
+			}
+			return next(ec)
+		}
+	}
+}
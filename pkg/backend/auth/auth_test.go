@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testServer(t *testing.T, authn Authenticator) *echo.Echo {
+	eng := echo.New()
+	Configure(eng, authn)
+	eng.GET("/status", func(ec echo.Context) error { return ec.String(http.StatusOK, "ok") })
+	eng.GET("/api/providers", func(ec echo.Context) error {
+		require.NotNil(t, UserFrom(ec))
+		return ec.String(http.StatusOK, UserFrom(ec).Name)
+	})
+	return eng
+}
+
+func serve(eng *echo.Echo, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	eng.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTokenAuth(t *testing.T) {
+	authn, err := New(context.Background(), Config{Mode: ModeToken, Token: "s3cret"})
+	require.NoError(t, err)
+	eng := testServer(t, authn)
+
+	rec := serve(eng, httptest.NewRequest(http.MethodGet, "/api/providers", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serve(eng, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/providers", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, serve(eng, req).Code)
+
+	req.Header.Set(echo.HeaderAuthorization, "Bearer s3cret")
+	rec = serve(eng, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, tokenUser, rec.Body.String())
+
+	// token in URL turns into session cookie
+	rec = serve(eng, httptest.NewRequest(http.MethodGet, "/api/providers?token=s3cret", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/providers", nil)
+	req.AddCookie(cookies[0])
+	assert.Equal(t, http.StatusOK, serve(eng, req).Code)
+}
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "users")
+	content := "# comment\n\nalice:" + string(hash) + ":devs,ops\nbob:" + string(hash) + "\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+
+	authn, err := New(context.Background(), Config{Mode: ModeBasic, BasicFile: file})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devs", "ops"}, authn.(*basicAuth).users["alice"].groups)
+	eng := testServer(t, authn)
+
+	rec := serve(eng, httptest.NewRequest(http.MethodGet, "/api/providers", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), "Basic")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/providers", nil)
+	req.SetBasicAuth("bob", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, serve(eng, req).Code)
+
+	req.SetBasicAuth("bob", "pass")
+	rec = serve(eng, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bob", rec.Body.String())
+}
+
+func TestParseUsers_Malformed(t *testing.T) {
+	for _, content := range []string{"alice", "alice:plaintext", ":$2y$10$abc"} {
+		_, err := parseUsers(bufio.NewScanner(strings.NewReader(content)))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestHeaderAuth(t *testing.T) {
+	authn, err := New(context.Background(), Config{Mode: ModeHeader})
+	require.NoError(t, err)
+	eng := testServer(t, authn)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(eng, httptest.NewRequest(http.MethodGet, "/api/providers", nil)).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/user", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	req.Header.Set("X-Forwarded-Groups", "devs, ops,")
+	rec := serve(eng, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"name": "alice", "groups": ["devs", "ops"]}`, rec.Body.String())
+}
+
+func TestNoAuth(t *testing.T) {
+	authn, err := New(context.Background(), Config{Mode: ModeNone})
+	require.NoError(t, err)
+	assert.Nil(t, authn)
+
+	_, err = New(context.Background(), Config{Mode: "magic"})
+	assert.Error(t, err)
+}
+
+func TestSessions(t *testing.T) {
+	s, err := newSessions("key", time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	value, err := s.encode(&User{Name: "alice", Groups: []string{"devs"}}, now)
+	require.NoError(t, err)
+
+	user, err := s.decode(value, now)
+	require.NoError(t, err)
+	assert.Equal(t, &User{Name: "alice", Groups: []string{"devs"}}, user)
+
+	_, err = s.decode(value, now.Add(2*time.Hour))
+	assert.Error(t, err, "expired")
+
+	_, err = s.decode("x"+value, now)
+	assert.Error(t, err, "tampered")
+
+	other, err := newSessions("other", time.Hour)
+	require.NoError(t, err)
+	_, err = other.decode(value, now)
+	assert.Error(t, err, "different key")
+}
+
+func TestUserFromClaims(t *testing.T) {
+	user := userFromClaims(map[string]interface{}{
+		"sub":    "123",
+		"email":  "alice@example.org",
+		"groups": []interface{}{"devs", "ops"},
+	}, "groups")
+	assert.Equal(t, &User{Name: "alice@example.org", Groups: []string{"devs", "ops"}}, user)
+
+	user = userFromClaims(map[string]interface{}{"sub": "123", "roles": "admin"}, "roles")
+	assert.Equal(t, &User{Name: "123", Groups: []string{"admin"}}, user)
+}
+
+func TestMetricsAuth(t *testing.T) {
+	authn, err := New(context.Background(), Config{Mode: ModeToken, Token: "s3cret"})
+	require.NoError(t, err)
+
+	metrics := func(token string) *echo.Echo {
+		eng := testServer(t, authn)
+		eng.GET("/metrics", func(ec echo.Context) error { return ec.String(http.StatusOK, "up 1") }, BearerToken(token))
+		return eng
+	}
+
+	rec := serve(metrics(""), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "Prometheus scrapes without the auth mode credentials")
+
+	eng := metrics("scrape")
+	assert.Equal(t, http.StatusUnauthorized, serve(eng, httptest.NewRequest(http.MethodGet, "/metrics", nil)).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer s3cret")
+	assert.Equal(t, http.StatusUnauthorized, serve(eng, req).Code, "own token only")
+
+	req.Header.Set(echo.HeaderAuthorization, "Bearer scrape")
+	assert.Equal(t, http.StatusOK, serve(eng, req).Code)
+}
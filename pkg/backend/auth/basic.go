@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type basicUser struct {
+	hash   []byte
+	groups []string
+}
+
+// basicAuth checks the users from htpasswd-like file, with lines of `user:bcrypt-hash[:group1,group2]`
+type basicAuth struct {
+	users map[string]basicUser
+}
+
+func newBasicAuth(cfg Config) (*basicAuth, error) {
+	if cfg.BasicFile == "" {
+		return nil, errors.New("basic auth requires the users file to be set")
+	}
+
+	f, err := os.Open(cfg.BasicFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open users file")
+	}
+	defer func() { _ = f.Close() }()
+
+	users, err := parseUsers(bufio.NewScanner(f))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Loaded %d users for basic auth", len(users))
+	return &basicAuth{users: users}, nil
+}
+
+func parseUsers(scanner *bufio.Scanner) (map[string]basicUser, error) {
+	users := map[string]basicUser{}
+	for num := 1; scanner.Scan(); num++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, errors.Errorf("malformed users file line %d", num)
+		}
+
+		if _, err := bcrypt.Cost([]byte(parts[1])); err != nil {
+			return nil, errors.Errorf("users file line %d does not have bcrypt hash", num)
+		}
+
+		user := basicUser{hash: []byte(parts[1])}
+		if len(parts) == 3 && parts[2] != "" {
+			user.groups = strings.Split(parts[2], ",")
+		}
+		users[parts[0]] = user
+	}
+	return users, scanner.Err()
+}
+
+func (a *basicAuth) Authenticate(ec echo.Context) (*User, error) {
+	name, password, ok := ec.Request().BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+
+	user, found := a.users[name]
+	if !found {
+		return nil, errors.Errorf("unknown user %s", name)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.hash, []byte(password)); err != nil {
+		return nil, errors.Errorf("wrong password for user %s", name)
+	}
+
+	return &User{Name: name, Groups: user.groups}, nil
+}
+
+func (a *basicAuth) Challenge(ec echo.Context) error {
+	ec.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="komoplane"`)
+	return unauthorized()
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// headerAuth trusts the identity set by authenticating proxy in front of komoplane, like oauth2-proxy.
+// Make sure komoplane is not reachable bypassing the proxy in this mode.
+type headerAuth struct {
+	userHeader   string
+	groupsHeader string
+}
+
+func newHeaderAuth(cfg Config) *headerAuth {
+	res := &headerAuth{
+		userHeader:   cfg.HeaderUser,
+		groupsHeader: cfg.HeaderGroups,
+	}
+
+	if res.userHeader == "" {
+		res.userHeader = "X-Forwarded-User"
+	}
+
+	if res.groupsHeader == "" {
+		res.groupsHeader = "X-Forwarded-Groups"
+	}
+
+	return res
+}
+
+func (a *headerAuth) Authenticate(ec echo.Context) (*User, error) {
+	name := ec.Request().Header.Get(a.userHeader)
+	if name == "" {
+		return nil, nil
+	}
+
+	user := &User{Name: name}
+	for _, group := range strings.Split(ec.Request().Header.Get(a.groupsHeader), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			user.Groups = append(user.Groups, group)
+		}
+	}
+	return user, nil
+}
+
+func (a *headerAuth) Challenge(_ echo.Context) error {
+	return unauthorized()
+}
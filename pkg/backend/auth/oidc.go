@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	stateCookie    = "komoplane_oidc_state"
+	redirectCookie = "komoplane_oidc_redirect"
+)
+
+// oidcAuth logs users in with OpenID Connect provider, keeping the identity in session cookie afterwards
+type oidcAuth struct {
+	ctx         context.Context
+	oauth       oauth2.Config
+	verifier    *oidc.IDTokenVerifier
+	groupsClaim string
+	sessions    *sessions
+}
+
+func newOIDCAuth(ctx context.Context, cfg Config) (*oidcAuth, error) {
+	if cfg.OIDCIssuer == "" || cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "" {
+		return nil, errors.New("OIDC auth requires issuer, client ID and redirect URL to be set")
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.OIDCIssuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to discover OIDC provider")
+	}
+
+	s, err := newSessions(cfg.SessionSecret, cfg.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := cfg.OIDCScopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email", "groups"}
+	}
+
+	res := &oidcAuth{
+		ctx: ctx,
+		oauth: oauth2.Config{
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		},
+		verifier:    provider.Verifier(&oidc.Config{ClientID: cfg.OIDCClientID}),
+		groupsClaim: cfg.OIDCGroupsClaim,
+		sessions:    s,
+	}
+
+	if res.groupsClaim == "" {
+		res.groupsClaim = "groups"
+	}
+
+	return res, nil
+}
+
+func (a *oidcAuth) Authenticate(ec echo.Context) (*User, error) {
+	return a.sessions.Get(ec)
+}
+
+func (a *oidcAuth) Challenge(ec echo.Context) error {
+	if wantsJSON(ec) {
+		return unauthorized()
+	}
+
+	ec.SetCookie(a.tempCookie(ec, redirectCookie, ec.Request().URL.RequestURI()))
+	return ec.Redirect(http.StatusFound, "/auth/login")
+}
+
+func (a *oidcAuth) Routes(g *echo.Group) {
+	g.GET("/login", a.login)
+	g.GET("/callback", a.callback)
+	g.GET("/logout", a.logout)
+}
+
+func (a *oidcAuth) login(ec echo.Context) error {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	state := base64.RawURLEncoding.EncodeToString(raw)
+
+	ec.SetCookie(a.tempCookie(ec, stateCookie, state))
+	return ec.Redirect(http.StatusFound, a.oauth.AuthCodeURL(state))
+}
+
+func (a *oidcAuth) callback(ec echo.Context) error {
+	state, err := ec.Cookie(stateCookie)
+	if err != nil || state.Value == "" || state.Value != ec.QueryParam("state") {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid login state, try logging in again")
+	}
+
+	if errParam := ec.QueryParam("error"); errParam != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed: "+errParam+" "+ec.QueryParam("error_description"))
+	}
+
+	token, err := a.oauth.Exchange(ec.Request().Context(), ec.QueryParam("code"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to exchange login code").SetInternal(err)
+	}
+
+	rawID, ok := token.Extra("id_token").(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no ID token in login response")
+	}
+
+	idToken, err := a.verifier.Verify(ec.Request().Context(), rawID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid ID token").SetInternal(err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return err
+	}
+
+	user := userFromClaims(claims, a.groupsClaim)
+	log.Infof("User %s logged in", user.Name)
+	if err := a.sessions.Set(ec, user); err != nil {
+		return err
+	}
+
+	target := "/"
+	if redirect, err := ec.Cookie(redirectCookie); err == nil && strings.HasPrefix(redirect.Value, "/") && !strings.HasPrefix(redirect.Value, "//") {
+		target = redirect.Value
+	}
+	return ec.Redirect(http.StatusFound, target)
+}
+
+func (a *oidcAuth) logout(ec echo.Context) error {
+	a.sessions.Clear(ec)
+	return ec.Redirect(http.StatusFound, "/")
+}
+
+func (a *oidcAuth) tempCookie(ec echo.Context, name string, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   ec.IsTLS(),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// userFromClaims prefers human-readable name claims over subject ID
+func userFromClaims(claims map[string]interface{}, groupsClaim string) *User {
+	user := &User{}
+	for _, claim := range []string{"preferred_username", "email", "sub"} {
+		if name, ok := claims[claim].(string); ok && name != "" {
+			user.Name = name
+			break
+		}
+	}
+
+	switch groups := claims[groupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if str, ok := group.(string); ok {
+				user.Groups = append(user.Groups, str)
+			}
+		}
+	case string:
+		user.Groups = []string{groups}
+	}
+
+	return user
+}
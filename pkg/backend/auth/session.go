@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const sessionCookie = "komoplane_session"
+
+// sessions keeps the user in signed cookie, so there is no server-side state to share between replicas
+type sessions struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+type sessionData struct {
+	User    User  `json:"user"`
+	Expires int64 `json:"exp"`
+}
+
+func newSessions(secret string, ttl time.Duration) (*sessions, error) {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &sessions{secret: key, ttl: ttl}, nil
+}
+
+func (s *sessions) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *sessions) encode(user *User, now time.Time) (string, error) {
+	raw, err := json.Marshal(sessionData{User: *user, Expires: now.Add(s.ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + s.sign(payload), nil
+}
+
+func (s *sessions) decode(value string, now time.Time) (*User, error) {
+	payload, sig, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return nil, errors.New("invalid session signature")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	data := sessionData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+
+	if now.Unix() > data.Expires {
+		return nil, errors.New("session expired")
+	}
+
+	return &data.User, nil
+}
+
+// Get returns user from session cookie, nil if there is no valid session
+func (s *sessions) Get(ec echo.Context) (*User, error) {
+	cookie, err := ec.Cookie(sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	return s.decode(cookie.Value, time.Now())
+}
+
+func (s *sessions) Set(ec echo.Context, user *User) error {
+	value, err := s.encode(user, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ec.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(s.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   ec.IsTLS(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (s *sessions) Clear(ec echo.Context) {
+	ec.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const tokenUser = "token"
+
+// tokenAuth accepts static bearer token. To open UI in browser, pass it once as `?token=` and it gets into session cookie.
+type tokenAuth struct {
+	token    string
+	sessions *sessions
+}
+
+func newTokenAuth(cfg Config) (*tokenAuth, error) {
+	if cfg.Token == "" {
+		return nil, errors.New("token auth requires the token to be set")
+	}
+
+	s, err := newSessions(cfg.SessionSecret, cfg.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenAuth{token: cfg.Token, sessions: s}, nil
+}
+
+func (a *tokenAuth) Authenticate(ec echo.Context) (*User, error) {
+	if token, found := strings.CutPrefix(ec.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); found {
+		if !a.valid(token) {
+			return nil, errors.New("invalid bearer token")
+		}
+		return &User{Name: tokenUser}, nil
+	}
+
+	if token := ec.QueryParam("token"); token != "" {
+		if !a.valid(token) {
+			return nil, errors.New("invalid token in query")
+		}
+		user := &User{Name: tokenUser}
+		return user, a.sessions.Set(ec, user)
+	}
+
+	return a.sessions.Get(ec)
+}
+
+func (a *tokenAuth) valid(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+func (a *tokenAuth) Challenge(ec echo.Context) error {
+	ec.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	if wantsJSON(ec) {
+		return unauthorized()
+	}
+	return ec.String(http.StatusUnauthorized, "Authentication required, open komoplane with ?token=<token> in URL")
+}
@@ -0,0 +1,280 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	headerCluster = "X-Komoplane-Cluster"
+	inClusterName = "in-cluster"
+
+	minRetryDelay = 5 * time.Second // after failing to connect to a cluster, doubled with each failure
+	maxRetryDelay = 5 * time.Minute
+)
+
+// Cluster is the public info about one of the clusters komoplane can work with
+type Cluster struct {
+	Name        string `json:"name"`
+	Server      string `json:"server"`
+	Default     bool   `json:"default"`
+	Initialized bool   `json:"initialized"`
+}
+
+// Clusters holds connection configs for all known clusters and creates a Controller for each of them on first use
+type Clusters struct {
+	ctx         context.Context
+	namespace   string
+	status      *StatusInfo
+	configs     map[string]*rest.Config
+	defaultName string
+	connect     func(ctx context.Context, cfg *rest.Config, ns string, status *StatusInfo) (*Controller, error)
+
+	mx          sync.Mutex // guards the maps only, connecting is done outside of it
+	controllers map[string]*Controller
+	connections map[string]*connection
+}
+
+// connection serializes attempts to connect to one cluster and remembers the last failure
+type connection struct {
+	mx      sync.Mutex
+	err     error
+	delay   time.Duration
+	retryAt time.Time
+}
+
+func NewClusters(ctx context.Context, configs map[string]*rest.Config, defaultName string, ns string, status *StatusInfo) *Clusters {
+	return &Clusters{
+		ctx:         ctx,
+		namespace:   ns,
+		status:      status,
+		configs:     configs,
+		defaultName: defaultName,
+		connect:     NewController,
+		controllers: map[string]*Controller{},
+		connections: map[string]*connection{},
+	}
+}
+
+// Get returns the controller for cluster, empty name means default cluster
+func (r *Clusters) Get(name string) (*Controller, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+
+	cfg, found := r.configs[name]
+	if !found {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "unknown cluster: "+name)
+	}
+
+	c, conn := r.lookup(name)
+	if c != nil {
+		return c, nil
+	}
+
+	// requests to the same cluster wait for single attempt, the other clusters are not affected
+	conn.mx.Lock()
+	defer conn.mx.Unlock()
+
+	if c, _ := r.lookup(name); c != nil {
+		return c, nil
+	}
+
+	if conn.err != nil && time.Now().Before(conn.retryAt) {
+		return nil, conn.err
+	}
+
+	log.Infof("Connecting to cluster %s", name)
+	c, err := r.connect(r.ctx, cfg, r.namespace, r.status)
+	if err != nil {
+		conn.delay = min(max(conn.delay*2, minRetryDelay), maxRetryDelay)
+		conn.retryAt = time.Now().Add(conn.delay)
+		conn.err = errors.Wrapf(err, "failed to connect to cluster %s", name)
+		log.Warnf("%v, will retry in %s", conn.err, conn.delay)
+		return nil, conn.err
+	}
+	c.Cluster = name
+
+	r.mx.Lock()
+	r.controllers[name] = c
+	delete(r.connections, name)
+	r.mx.Unlock()
+
+	return c, nil
+}
+
+// lookup returns either the controller of cluster or its connection to use for connecting
+func (r *Clusters) lookup(name string) (*Controller, *connection) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if c, found := r.controllers[name]; found {
+		return c, nil
+	}
+
+	conn, found := r.connections[name]
+	if !found {
+		conn = &connection{}
+		r.connections[name] = conn
+	}
+	return nil, conn
+}
+
+// ForRequest picks the cluster by header, which is also set for `/api/clusters/<name>/...` paths
+func (r *Clusters) ForRequest(ec echo.Context) (*Controller, error) {
+	return r.Get(ec.Request().Header.Get(headerCluster))
+}
+
+// Initialized returns controllers that were created so far
+func (r *Clusters) Initialized() []*Controller {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	res := make([]*Controller, 0, len(r.controllers))
+	for _, c := range r.controllers {
+		res = append(res, c)
+	}
+	return res
+}
+
+// Handle binds controller method to the cluster selected by request
+func (r *Clusters) Handle(method func(*Controller, echo.Context) error) echo.HandlerFunc {
+	return func(ec echo.Context) error {
+		c, err := r.ForRequest(ec)
+		if err != nil {
+			return err
+		}
+		return method(c, ec)
+	}
+}
+
+func (r *Clusters) GetClusters(ec echo.Context) error {
+	r.mx.Lock()
+	res := []Cluster{}
+	for name, cfg := range r.configs {
+		_, initialized := r.controllers[name]
+		res = append(res, Cluster{
+			Name:        name,
+			Server:      cfg.Host,
+			Default:     name == r.defaultName,
+			Initialized: initialized,
+		})
+	}
+	r.mx.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return ec.JSONPretty(http.StatusOK, res, "  ")
+}
+
+// clusterPrefix turns `/api/clusters/<name>/<rest>` into `/api/<rest>` with cluster header
+func clusterPrefix(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if rest, found := strings.CutPrefix(req.URL.Path, "/api/clusters/"); found {
+			name, sub, hasSub := strings.Cut(rest, "/")
+			if hasSub && name != "" {
+				req.Header.Set(headerCluster, name)
+				req.URL.Path = "/api/" + sub
+				req.URL.RawPath = ""
+			}
+		}
+		return next(c)
+	}
+}
+
+// getK8sConfigs loads the configs for requested contexts of kubeconfig, falling back to single current context
+func getK8sConfigs(contexts []string, allContexts bool) (map[string]*rest.Config, string, error) {
+	if len(contexts) == 0 && !allContexts {
+		cfg, name, err := getK8sConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		return map[string]*rest.Config{name: cfg}, name, nil
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	raw, err := loadingRules.Load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if allContexts {
+		contexts = []string{}
+		for name := range raw.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+	}
+
+	defaultName := contexts[0]
+	if _, found := raw.Contexts[raw.CurrentContext]; allContexts && found {
+		defaultName = raw.CurrentContext
+	}
+
+	configs := map[string]*rest.Config{}
+	for _, name := range contexts {
+		if _, found := raw.Contexts[name]; !found {
+			return nil, "", errors.Errorf("context %s is not found in kubeconfig", name)
+		}
+
+		cfg, err := clientcmd.NewNonInteractiveClientConfig(*raw, name, &clientcmd.ConfigOverrides{}, loadingRules).ClientConfig()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to load context %s", name)
+		}
+		configs[name] = cfg
+	}
+
+	if len(configs) == 0 {
+		return nil, "", errors.New("no contexts found in kubeconfig")
+	}
+
+	log.Infof("Loaded %d cluster contexts, default is %s", len(configs), defaultName)
+	return configs, defaultName, nil
+}
+
+func getK8sConfig() (*rest.Config, string, error) {
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		log.Infof("Using in-cluster Kubernetes connection")
+		return config, inClusterName, nil
+	}
+
+	log.Debugf("Failed to connect in-cluster: %v", err)
+
+	//kubeconfig := os.Getenv("KUBECONFIG")
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{
+		CurrentContext: os.Getenv("KUBECONTEXT"),
+	}
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+
+	config, err = kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := configOverrides.CurrentContext
+	if name == "" {
+		raw, err := kubeConfig.RawConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		name = raw.CurrentContext
+	}
+
+	return config, name, nil
+}
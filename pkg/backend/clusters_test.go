@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func testClusters() *Clusters {
+	configs := map[string]*rest.Config{
+		"prod":    {Host: "https://prod.example.org"},
+		"staging": {Host: "https://staging.example.org"},
+	}
+	clusters := NewClusters(context.Background(), configs, "prod", "", &StatusInfo{})
+	for name := range configs {
+		clusters.controllers[name] = &Controller{Cluster: name} // not to connect anywhere
+	}
+	return clusters
+}
+
+func TestClusters_Routing(t *testing.T) {
+	clusters := testClusters()
+
+	eng := echo.New()
+	eng.Pre(clusterPrefix)
+	eng.GET("/api/clusters", clusters.GetClusters)
+	eng.GET("/api/providers/:name", clusters.Handle(func(c *Controller, ec echo.Context) error {
+		return ec.String(http.StatusOK, c.Cluster+"/"+ec.Param("name"))
+	}))
+
+	tests := []struct {
+		path   string
+		header string
+		code   int
+		body   string
+	}{
+		{path: "/api/providers/aws", code: http.StatusOK, body: "prod/aws"},
+		{path: "/api/providers/aws", header: "staging", code: http.StatusOK, body: "staging/aws"},
+		{path: "/api/clusters/staging/providers/aws", code: http.StatusOK, body: "staging/aws"},
+		{path: "/api/clusters/staging/providers/aws", header: "prod", code: http.StatusOK, body: "staging/aws"},
+		{path: "/api/clusters/dev/providers/aws", code: http.StatusNotFound},
+		{path: "/api/providers/aws", header: "dev", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(headerCluster, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			eng.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	eng.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/clusters", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	list := []Cluster{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, []Cluster{
+		{Name: "prod", Server: "https://prod.example.org", Default: true, Initialized: true},
+		{Name: "staging", Server: "https://staging.example.org", Initialized: true},
+	}, list)
+}
+
+func TestClusters_Connect(t *testing.T) {
+	clusters := NewClusters(context.Background(), map[string]*rest.Config{
+		"slow":   {Host: "https://slow.example.org"},
+		"broken": {Host: "https://broken.example.org"},
+	}, "slow", "", &StatusInfo{})
+
+	release := make(chan struct{})
+	calls := map[string]int{}
+	callsMx := sync.Mutex{}
+	clusters.connect = func(_ context.Context, cfg *rest.Config, _ string, _ *StatusInfo) (*Controller, error) {
+		callsMx.Lock()
+		calls[cfg.Host]++
+		callsMx.Unlock()
+
+		if cfg.Host == "https://broken.example.org" {
+			return nil, errors.New("connection refused")
+		}
+		<-release
+		return &Controller{}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := clusters.Get("slow")
+			assert.NoError(t, err)
+			assert.Equal(t, "slow", c.Cluster)
+		}()
+	}
+
+	// while slow cluster is connecting, the others are served
+	_, err := clusters.Get("broken")
+	assert.EqualError(t, err, "failed to connect to cluster broken: connection refused")
+	_, err = clusters.Get("broken")
+	assert.Error(t, err)
+	assert.Empty(t, clusters.Initialized())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, map[string]int{"https://slow.example.org": 1, "https://broken.example.org": 1}, calls,
+		"concurrent requests share the attempt, failure is not retried at once")
+	assert.Len(t, clusters.Initialized(), 1)
+}
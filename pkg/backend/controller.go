@@ -14,8 +14,9 @@ import (
 	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite" // what's the difference between `composed` and `composite` there?
 	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
-	"github.com/jellydator/ttlcache/v3"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
 	"github.com/komodorio/komoplane/pkg/backend/crossplane"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
 	"github.com/komodorio/komoplane/pkg/backend/utils"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
@@ -27,9 +28,10 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 type StatusInfo struct {
@@ -37,19 +39,26 @@ type StatusInfo struct {
 	LatestVer           string
 	Analytics           bool
 	CrossplaneInstalled bool
+	ActionsEnabled      bool
+	Impersonate         bool
+	Cluster             string
 }
 
 type Controller struct {
-	StatusInfo StatusInfo
-	APIv1      crossplane.APIv1
-	ExtV1      crossplane.ExtensionsV1
-	Events     crossplane.EventsInterface
-	CRDs       crossplane.CRDInterface
-	XRDs       crossplane.XRDInterface
-	ctx        context.Context
-	apiExt     *apiextensionsv1.ApiextensionsV1Client
-	mrdCache   *ttlcache.Cache[bool, []*v1.CustomResourceDefinition] // TODO: extract this into separate entity
-	mrCache    *ttlcache.Cache[bool, *unstructured.UnstructuredList]
+	StatusInfo  *StatusInfo // shared between clusters
+	Cluster     string
+	APIv1       crossplane.APIv1
+	ExtV1       crossplane.ExtensionsV1
+	Events      crossplane.EventsInterface
+	CRDs        crossplane.CRDInterface
+	XRDs        crossplane.XRDInterface
+	Tracker     *tracker.Tracker
+	ctx         context.Context
+	apiExt      *apiextensionsv1.ApiextensionsV1Client
+	namespace   string // empty means all namespaces
+	access      *accessChecker
+	secrets     corev1client.SecretsGetter
+	callTimeout time.Duration // per API call when fanning out over many kinds or resources
 }
 
 type ConditionedObject interface {
@@ -105,17 +114,55 @@ func (m *ManagedUnstructured) SetProviderConfigReference(_ *xpv1.Reference) {
 func (c *Controller) GetStatus() StatusInfo {
 	name := utils.Plural(cpv1.ProviderKind) + "." + cpv1.Group
 	crd, err := c.apiExt.CustomResourceDefinitions().Get(c.ctx, name, metav1.GetOptions{})
-	if err != nil {
+	if err != nil && !k8sErrors.IsForbidden(err) {
 		log.Warnf("Failed to get provider CRD, Crossplane is not installed: %s", err)
 	}
 
-	c.StatusInfo.CrossplaneInstalled = crd != nil && err == nil
+	res := *c.StatusInfo
+	res.CrossplaneInstalled = crd != nil && err == nil || k8sErrors.IsForbidden(err) // can't tell, so let's hope it is
+	res.Cluster = c.Cluster
 
-	return c.StatusInfo
+	return res
+}
+
+// reqCtx carries the identity of viewer into Kubernetes calls made for the request, when impersonation is on
+func (c *Controller) reqCtx(ec echo.Context) context.Context {
+	ctx := ec.Request().Context()
+	if user := auth.UserFrom(ec); user != nil && c.StatusInfo.Impersonate {
+		ctx = crossplane.WithImpersonation(ctx, &crossplane.Impersonation{User: user.Name, Groups: user.Groups})
+	}
+	return ctx
+}
+
+// markForbidden lets UI show that the section is hidden by RBAC, rather than missing
+func markForbidden(obj ConditionedObject) {
+	if u, ok := obj.(runtime.Unstructured); ok {
+		u.UnstructuredContent()["forbidden"] = true
+	}
+}
+
+// inScope rejects namespaced objects outside the namespace komoplane is limited to
+func (c *Controller) inScope(namespace string) error {
+	if c.namespace != "" && namespace != "" && namespace != c.namespace {
+		return echo.NewHTTPError(http.StatusForbidden, "komoplane is limited to namespace "+c.namespace)
+	}
+	return nil
+}
+
+// forbidden tells that cluster-scoped list is not allowed, so the view should be empty instead of failing
+func forbidden(err error, what string) bool {
+	if k8sErrors.IsForbidden(err) {
+		log.Debugf("Not allowed to list %s, returning empty list: %v", what, err)
+		return true
+	}
+	return false
 }
 
 func (c *Controller) GetProviders(ec echo.Context) error {
-	providers, err := c.APIv1.Providers().List(c.ctx)
+	providers, err := c.APIv1.Providers().List(c.reqCtx(ec))
+	if forbidden(err, "providers") {
+		providers, err = &cpv1.ProviderList{}, nil
+	}
 	if err != nil {
 		return err
 	}
@@ -124,7 +171,7 @@ func (c *Controller) GetProviders(ec echo.Context) error {
 }
 
 func (c *Controller) GetProvider(ec echo.Context) error {
-	res, err := c.APIv1.Providers().Get(c.ctx, ec.Param("name"))
+	res, err := c.APIv1.Providers().Get(c.reqCtx(ec), ec.Param("name"))
 	if err != nil {
 		return err
 	}
@@ -144,7 +191,7 @@ func (c *Controller) GetProviderEvents(ec echo.Context) error {
 	}
 	ref.SetGroupVersionKind(gvk)
 
-	res, err := c.Events.List(c.ctx, &ref)
+	res, err := c.Events.List(c.reqCtx(ec), &ref)
 	if err != nil {
 		return err
 	}
@@ -161,13 +208,29 @@ func (c *Controller) GetProviderConfigs(ec echo.Context) error {
 	return ec.JSONPretty(http.StatusOK, res, "  ")
 }
 
+// GetProviderResources lists summaries of MRs of provider kinds, optionally only those using specific ProviderConfig
+func (c *Controller) GetProviderResources(ec echo.Context) error {
+	query, err := parseListQuery(ec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.APIv1.Providers().Get(c.reqCtx(ec), ec.Param("name")); err != nil {
+		return err
+	}
+
+	query.Provider = ec.Param("name")
+	query.ProviderConfig = ec.Param("config")
+	return c.respondTracked(ec, tracker.ClassManaged, query, viewSummary)
+}
+
 func (c *Controller) GetProviderConfigsInner(ec echo.Context, provName string) (*unstructured.UnstructuredList, error) {
 	allProvCRDs, err := c.LoadCRDs(ec)
 	if err != nil {
 		return nil, err
 	}
 
-	list := unstructured.UnstructuredList{Items: []unstructured.Unstructured{}}
+	crds := []*v1.CustomResourceDefinition{}
 	for k, provCRDs := range allProvCRDs {
 		if provName != "" && k != provName {
 			continue
@@ -176,46 +239,68 @@ func (c *Controller) GetProviderConfigsInner(ec echo.Context, provName string) (
 		for _, crd := range provCRDs {
 			// we're relying here on the naming standard for CRDs in all providers, which is not guaranteed
 			if crd.Spec.Names.Kind == cpk8s.ProviderConfigKind {
-				gvk := schema.GroupVersionKind{
-					Group:   crd.Spec.Group,
-					Version: crd.Spec.Versions[0].Name,
-					Kind:    crd.Spec.Names.Plural,
-				}
-				res, err := c.CRDs.List(c.ctx, gvk)
-				if err != nil {
-					return nil, err
-				}
-				list.Items = append(list.Items, res.Items...)
+				crds = append(crds, crd)
 			}
 		}
 	}
 
+	ctx := c.reqCtx(ec)
+	lists := make([]*unstructured.UnstructuredList, len(crds))
+	errs := c.fanOut(ctx, len(crds), func(ctx context.Context, i int) (err error) {
+		gvk := schema.GroupVersionKind{
+			Group:   crds[i].Spec.Group,
+			Version: crds[i].Spec.Versions[0].Name,
+			Kind:    crds[i].Spec.Names.Plural,
+		}
+		lists[i], err = c.CRDs.List(ctx, gvk)
+		return err
+	})
+
+	list := unstructured.UnstructuredList{Object: map[string]interface{}{}, Items: []unstructured.Unstructured{}}
+	var warnings []Warning
+	for i, err := range errs {
+		if forbidden(err, crds[i].Name) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err() // nobody waits for the response anymore
+		}
+		if err != nil {
+			log.Warnf("Failed to list %s: %v", crds[i].Name, err)
+			warnings = append(warnings, Warning{Kind: crds[i].Spec.Names.Kind + "." + crds[i].Spec.Group, Message: err.Error()})
+			continue
+		}
+		list.Items = append(list.Items, lists[i].Items...)
+	}
+
+	if len(warnings) > 0 {
+		list.Object["warnings"] = warnings
+	}
 	return &list, nil
 }
 
 func (c *Controller) LoadCRDs(ec echo.Context) (CRDMap, error) {
 	// FIXME: a misplaced method! Should be in some data layer class
-	// FIXME: quite expensive method to call
 	if ec.Get("LoadCRDs") != nil {
 		log.Warnf("Heavy call made twice")
 	}
 	ec.Set("LoadCRDs", true)
 
-	// Create the API Extensions clientset
-	providers, err := c.APIv1.Providers().List(c.ctx)
-	if err != nil {
-		return nil, err
-	}
+	return c.loadProviderCRDs(c.reqCtx(ec))
+}
 
-	crdList, err := c.apiExt.CustomResourceDefinitions().List(c.ctx, metav1.ListOptions{})
+func (c *Controller) loadProviderCRDs(ctx context.Context) (CRDMap, error) {
+	providers, err := c.APIv1.Providers().List(ctx)
+	if forbidden(err, "providers") {
+		return CRDMap{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	provCRDs := CRDMap{}
 
-	for _, crd := range crdList.Items {
-		crdCopy := crd
+	for _, crd := range c.Tracker.CRDs(ctx) {
 	refLoop:
 		for _, ref := range crd.OwnerReferences {
 			isProvider := ref.Kind == cpv1.ProviderKind && ref.APIVersion == cpv1.Group+"/"+cpv1.Version
@@ -233,7 +318,7 @@ func (c *Controller) LoadCRDs(ec echo.Context) (CRDMap, error) {
 					if _, ok := provCRDs[prov.Name]; !ok {
 						provCRDs[prov.Name] = []*v1.CustomResourceDefinition{}
 					}
-					provCRDs[prov.Name] = append(provCRDs[prov.Name], &crdCopy)
+					provCRDs[prov.Name] = append(provCRDs[prov.Name], crd)
 					break refLoop
 				}
 			}
@@ -242,25 +327,78 @@ func (c *Controller) LoadCRDs(ec echo.Context) (CRDMap, error) {
 	return provCRDs, nil
 }
 
-func (c *Controller) GetClaims(ec echo.Context) error {
-	list := unstructured.UnstructuredList{
-		Object: nil,
-		Items:  []unstructured.Unstructured{},
+// providersByKind maps each MR kind onto the name of provider that has installed it
+func (c *Controller) providersByKind(ctx context.Context) (map[schema.GroupKind]string, error) {
+	provCRDs, err := c.loadProviderCRDs(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	err := c.fillXRDList(ec, &list, func(spec cpext.CompositeResourceDefinitionSpec) *string {
-		if spec.ClaimNames == nil { // the XRD allows it to be omitted
-			return nil
+	res := map[schema.GroupKind]string{}
+	for prov, crds := range provCRDs {
+		for _, crd := range crds {
+			res[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = prov
 		}
-		return &spec.ClaimNames.Plural
-	})
+	}
+	return res, nil
+}
+
+// listVisible returns tracked objects of the class that the viewer may list, with the kinds hidden by RBAC
+func (c *Controller) listVisible(ec echo.Context, class tracker.Class) ([]unstructured.Unstructured, []string) {
+	ctx := c.reqCtx(ec)
+	items := c.Tracker.List(ctx, class)
+	if user := auth.UserFrom(ec); user != nil && c.StatusInfo.Impersonate {
+		return c.access.filter(ctx, user, items, c.Tracker.ResourceFor)
+	}
+	return items, nil
+}
+
+func (c *Controller) listTracked(ec echo.Context, class tracker.Class) error {
+	query, err := parseListQuery(ec)
 	if err != nil {
 		return err
 	}
 
+	view, err := parseView(ec)
+	if err != nil {
+		return err
+	}
+
+	return c.respondTracked(ec, class, query, view)
+}
+
+func (c *Controller) respondTracked(ec echo.Context, class tracker.Class, query *ListQuery, view string) (err error) {
+	ctx := c.reqCtx(ec)
+	if query.Provider != "" {
+		query.providers, err = c.providersByKind(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	items, forbiddenKinds := c.listVisible(ec, class)
+	warnings := unsyncedWarnings(c.Tracker.Unsynced(class))
+	list := query.Apply(items)
+	if view == viewSummary {
+		summary := summarizeList(list)
+		summary.Forbidden = forbiddenKinds
+		summary.Warnings = warnings
+		return ec.JSONPretty(http.StatusOK, summary, "  ")
+	}
+
+	if len(forbiddenKinds) > 0 {
+		list.Object["forbidden"] = forbiddenKinds
+	}
+	if len(warnings) > 0 {
+		list.Object["warnings"] = warnings
+	}
 	return ec.JSONPretty(http.StatusOK, list, "  ")
 }
 
+func (c *Controller) GetClaims(ec echo.Context) error {
+	return c.listTracked(ec, tracker.ClassClaim)
+}
+
 func (c *Controller) GetClaim(ec echo.Context) error {
 	gvk := schema.GroupVersionKind{
 		Group:   ec.Param("group"),
@@ -271,8 +409,17 @@ func (c *Controller) GetClaim(ec echo.Context) error {
 	claimRef := v12.ObjectReference{Namespace: ec.Param("namespace"), Name: ec.Param("name")}
 	claimRef.SetGroupVersionKind(gvk)
 
+	if err := c.inScope(claimRef.Namespace); err != nil {
+		return err
+	}
+
+	depth, err := parseDepth(ec)
+	if err != nil {
+		return err
+	}
+
 	claim := uclaim.New()
-	err := c.getDynamicResource(&claimRef, claim)
+	err = c.getDynamicResource(c.reqCtx(ec), &claimRef, claim)
 	if err != nil {
 		return err
 	}
@@ -291,7 +438,7 @@ func (c *Controller) GetClaim(ec echo.Context) error {
 					objRef.Kind = kind
 				}
 			}
-			_ = c.getDynamicResource(objRef, xr)
+			_ = c.getDynamicResource(c.reqCtx(ec), objRef, xr)
 			claim.Object["compositeResource"] = xr
 		}
 
@@ -300,12 +447,20 @@ func (c *Controller) GetClaim(ec echo.Context) error {
 			return err
 		}
 
-		c.fillCompositionByRef(claim)
+		c.expandNestedXRs(ec, xr, depth)
+
+		if xrRef != nil && conditionStatus(&claim.Unstructured, xpv1.TypeReady) != string(v12.ConditionTrue) {
+			rootCauses, warnings := c.diagnose(ec, xr, 1)
+			claim.Object["rootCauses"] = rootCauses
+			addWarnings(&claim.Unstructured, warnings)
+		}
+
+		c.fillCompositionByRef(c.reqCtx(ec), claim)
 	}
 	return ec.JSONPretty(http.StatusOK, claim.Object, "  ")
 }
 
-func (c *Controller) fillCompositionByRef(obj UnstructuredWithCompositionRef) {
+func (c *Controller) fillCompositionByRef(ctx context.Context, obj UnstructuredWithCompositionRef) {
 	compRef := obj.GetCompositionReference()
 
 	// Fallback: extract composition ref manually for v2 Crossplane
@@ -323,7 +478,7 @@ func (c *Controller) fillCompositionByRef(obj UnstructuredWithCompositionRef) {
 
 	compRef.SetGroupVersionKind(cpext.CompositionGroupVersionKind)
 	comp := uxres.New()
-	err := c.getDynamicResource(compRef, comp)
+	err := c.getDynamicResource(ctx, compRef, comp)
 	if err != nil {
 		log.Debugf("Failed to get composition %s: %v", compRef.Name, err)
 		return
@@ -331,7 +486,7 @@ func (c *Controller) fillCompositionByRef(obj UnstructuredWithCompositionRef) {
 	obj.UnstructuredContent()["composition"] = comp
 }
 
-func (c *Controller) getDynamicResource(ref *v12.ObjectReference, res ConditionedObject) (err error) {
+func (c *Controller) getDynamicResource(ctx context.Context, ref *v12.ObjectReference, res ConditionedObject) (err error) {
 	res.SetAnnotations(map[string]string{})
 
 	if ref.Name == "" {
@@ -344,7 +499,7 @@ func (c *Controller) getDynamicResource(ref *v12.ObjectReference, res Conditione
 		}
 		res.SetConditions(condNotFound)
 	} else {
-		err = c.CRDs.Get(c.ctx, res, ref)
+		err = c.CRDs.Get(ctx, res, ref)
 		if err != nil {
 			condErrored := xpv1.Condition{
 				Type:               "Found",
@@ -353,6 +508,10 @@ func (c *Controller) getDynamicResource(ref *v12.ObjectReference, res Conditione
 				Reason:             "FailedToGet",
 				Message:            err.Error(),
 			}
+			if k8sErrors.IsForbidden(err) {
+				condErrored.Reason = "Forbidden"
+				markForbidden(res)
+			}
 			res.SetConditions(condErrored)
 		}
 	}
@@ -366,72 +525,7 @@ func (c *Controller) getDynamicResource(ref *v12.ObjectReference, res Conditione
 }
 
 func (c *Controller) GetManageds(ec echo.Context) error {
-	res := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{}}
-	cacheItem := c.mrCache.Get(true)
-	if cacheItem == nil {
-		log.Debugf("Missed cache for MRs, reloading...")
-		MRDs, err := c.getCachedMRDs(ec)
-		if err != nil {
-			return err
-		}
-
-		for _, mrd := range MRDs {
-			gvk := schema.GroupVersionKind{
-				Group:   mrd.Spec.Group,
-				Version: mrd.Spec.Versions[0].Name,
-				Kind:    mrd.Spec.Names.Plural,
-			}
-			items, err := c.CRDs.List(c.ctx, gvk)
-			if err != nil {
-				log.Warnf("Failed to list CRD: %v: %v", mrd.GroupVersionKind(), err)
-				continue
-			}
-
-			res.Items = append(res.Items, items.Items...)
-		}
-		c.mrCache.Set(true, res, ttlcache.DefaultTTL)
-	} else {
-		log.Debugf("Cache hit for MRs")
-		res = cacheItem.Value()
-	}
-
-	return ec.JSONPretty(http.StatusOK, res, "  ")
-}
-
-func (c *Controller) getCachedMRDs(ec echo.Context) ([]*v1.CustomResourceDefinition, error) {
-	var MRDs []*v1.CustomResourceDefinition
-	cacheItem := c.mrdCache.Get(true)
-	if cacheItem == nil {
-		// the assumption here is that the new kinds of MRs are rarely introduced
-		log.Debugf("Missed cache for MRDs, reloading...")
-		provCRDs, err := c.LoadCRDs(ec)
-		if err != nil {
-			return nil, err
-		}
-
-		MRDs = c.allMRDs(provCRDs)
-		c.mrdCache.Set(true, MRDs, ttlcache.DefaultTTL)
-	} else {
-		log.Debugf("Cache hit for MRDs")
-		MRDs = cacheItem.Value()
-	}
-	return MRDs, nil
-}
-
-func (c *Controller) allMRDs(provCRDs CRDMap) []*v1.CustomResourceDefinition {
-	res := []*v1.CustomResourceDefinition{}
-	for _, crds := range provCRDs {
-		for _, mrd := range crds {
-			if mrd.Spec.Names.Kind == cpk8s.ProviderConfigKind || mrd.Spec.Names.Kind == cpk8s.ProviderConfigUsageKind {
-				log.Debugf("Skipping %s/%s from listing of all MRs", mrd.Spec.Group, mrd.Spec.Names.Kind)
-				continue
-			}
-
-			res = append(res, mrd)
-		}
-	}
-
-	return res
+	return c.listTracked(ec, tracker.ClassManaged)
 }
 
 func (c *Controller) GetManaged(ec echo.Context) error {
@@ -444,7 +538,7 @@ func (c *Controller) GetManaged(ec echo.Context) error {
 	ref.SetGroupVersionKind(gvk)
 
 	xr := NewManagedUnstructured()
-	err := c.getDynamicResource(&ref, xr)
+	err := c.getDynamicResource(c.reqCtx(ec), &ref, xr)
 	if err != nil {
 		return err
 	}
@@ -468,7 +562,7 @@ func (c *Controller) GetManaged(ec echo.Context) error {
 			}
 
 			pc := uxres.New()
-			_ = c.getDynamicResource(&ref, pc)
+			_ = c.getDynamicResource(c.reqCtx(ec), &ref, pc)
 			xr.Object["provConfig"] = pc
 		}
 
@@ -481,7 +575,7 @@ func (c *Controller) GetManaged(ec echo.Context) error {
 				Name:       oRef.Name,
 				APIVersion: oRef.APIVersion,
 			}
-			_ = c.getDynamicResource(&ref, comp)
+			_ = c.getDynamicResource(c.reqCtx(ec), &ref, comp)
 			xr.Object["composite"] = comp
 		}
 	}
@@ -490,23 +584,14 @@ func (c *Controller) GetManaged(ec echo.Context) error {
 }
 
 func (c *Controller) GetComposites(ec echo.Context) error {
-	list := unstructured.UnstructuredList{
-		Object: nil,
-		Items:  []unstructured.Unstructured{},
-	}
-
-	err := c.fillXRDList(ec, &list, func(spec cpext.CompositeResourceDefinitionSpec) *string {
-		return &spec.Names.Plural
-	})
-	if err != nil {
-		return err
-	}
-
-	return ec.JSONPretty(http.StatusOK, list, "  ")
+	return c.listTracked(ec, tracker.ClassComposite)
 }
 
 func (c *Controller) GetCompositions(ec echo.Context) error {
-	items, err := c.ExtV1.Compositions().List(c.ctx)
+	items, err := c.ExtV1.Compositions().List(c.reqCtx(ec))
+	if forbidden(err, "compositions") {
+		items, err = &cpext.CompositionList{}, nil
+	}
 	if err != nil {
 		return err
 	}
@@ -520,7 +605,7 @@ func (c *Controller) GetComposition(ec echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "composition name is required")
 	}
 
-	composition, err := c.ExtV1.Compositions().Get(c.ctx, name)
+	composition, err := c.ExtV1.Compositions().Get(c.reqCtx(ec), name)
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			return echo.NewHTTPError(http.StatusNotFound, "composition not found")
@@ -551,7 +636,11 @@ func (c *Controller) GetEvents(ec echo.Context) error {
 	}
 	ref.SetGroupVersionKind(gvk)
 
-	res, err := c.Events.List(c.ctx, &ref)
+	if err := c.inScope(ref.Namespace); err != nil {
+		return err
+	}
+
+	res, err := c.Events.List(c.reqCtx(ec), &ref)
 	if err != nil {
 		return err
 	}
@@ -572,8 +661,17 @@ func (c *Controller) GetComposite(ec echo.Context) error {
 }
 
 func (c *Controller) getCompositeInner(ec echo.Context, ref *v12.ObjectReference) error {
+	if err := c.inScope(ref.Namespace); err != nil {
+		return err
+	}
+
+	depth, err := parseDepth(ec)
+	if err != nil {
+		return err
+	}
+
 	xr := uxres.New()
-	err := c.getDynamicResource(ref, xr)
+	err = c.getDynamicResource(c.reqCtx(ec), ref, xr)
 	if err != nil {
 		return err
 	}
@@ -596,7 +694,7 @@ func (c *Controller) getCompositeInner(ec echo.Context, ref *v12.ObjectReference
 				}
 			}
 			claim := uxres.New()
-			_ = c.getDynamicResource(objRef, claim)
+			_ = c.getDynamicResource(c.reqCtx(ec), objRef, claim)
 			xr.Object["claim"] = claim
 		}
 
@@ -615,18 +713,26 @@ func (c *Controller) getCompositeInner(ec echo.Context, ref *v12.ObjectReference
 
 			if nameMatch || cNameMatch {
 				parent := uxres.New()
-				_ = c.getDynamicResource(&objRef, parent)
+				_ = c.getDynamicResource(c.reqCtx(ec), &objRef, parent)
 				xr.Object["parentXR"] = parent
 			}
 		}
 
-		c.fillCompositionByRef(xr)
+		c.fillCompositionByRef(c.reqCtx(ec), xr)
 
 		// MR refs
 		err = c.fillManagedResources(ec, xr)
 		if err != nil {
 			return err
 		}
+
+		c.expandNestedXRs(ec, xr, depth)
+
+		if conditionStatus(&xr.Unstructured, xpv1.TypeReady) != string(v12.ConditionTrue) {
+			rootCauses, warnings := c.diagnose(ec, xr, 0)
+			xr.Object["rootCauses"] = rootCauses
+			addWarnings(&xr.Unstructured, warnings)
+		}
 	}
 
 	return ec.JSONPretty(http.StatusOK, xr, "  ")
@@ -644,8 +750,12 @@ func (c *Controller) GetManagedNamespaced(ec echo.Context) error {
 	}
 	ref.SetGroupVersionKind(gvk)
 
+	if err := c.inScope(ref.Namespace); err != nil {
+		return err
+	}
+
 	xr := NewManagedUnstructured()
-	err := c.getDynamicResource(&ref, xr)
+	err := c.getDynamicResource(c.reqCtx(ec), &ref, xr)
 	if err != nil {
 		return err
 	}
@@ -665,7 +775,7 @@ func (c *Controller) GetManagedNamespaced(ec echo.Context) error {
 				}
 			}
 			pc := uxres.New()
-			_ = c.getDynamicResource(&pcRef, pc)
+			_ = c.getDynamicResource(c.reqCtx(ec), &pcRef, pc)
 			xr.Object["provConfig"] = pc
 		}
 
@@ -676,7 +786,7 @@ func (c *Controller) GetManagedNamespaced(ec echo.Context) error {
 				Name:       oRef.Name,
 				APIVersion: oRef.APIVersion,
 			}
-			_ = c.getDynamicResource(&compRef, comp)
+			_ = c.getDynamicResource(c.reqCtx(ec), &compRef, comp)
 			xr.Object["composite"] = comp
 		}
 	}
@@ -699,71 +809,47 @@ func (c *Controller) GetCompositeNamespaced(ec echo.Context) error {
 	return c.getCompositeInner(ec, &ref)
 }
 
-func (c *Controller) fillManagedResources(ec echo.Context, xr *uxres.Unstructured) error {
+// fillManagedResources loads the resources of all the XRs with a single fan-out, so the API calls in flight stay bounded
+func (c *Controller) fillManagedResources(ec echo.Context, xrs ...*uxres.Unstructured) error {
 	xrds, err := c.cachedListXRDs(ec)
 	if err != nil {
 		return err
 	}
 
-	XRs := []v12.ObjectReference{}
-	claims := []v12.ObjectReference{}
-	MRs := []*ManagedUnstructured{}
-	for _, mrRef := range xr.GetResourceReferences() {
-		mr := NewManagedUnstructured()
-		err := c.getDynamicResource(&mrRef, mr)
-		if err != nil {
-			log.Debugf("Did not find dynamic resource %v", mrRef)
-		}
-
-		if mr.GetName() != "" { // skip those not found
-			nameMatched, claimNameMatched := c.matchXR(xrds, &mrRef)
-			if nameMatched {
-				XRs = append(XRs, mrRef)
-			} else if claimNameMatched {
-				claims = append(claims, mrRef)
+	refsOf := make([][]v12.ObjectReference, len(xrs))
+	allRefs := []v12.ObjectReference{}
+	for i, xr := range xrs {
+		refsOf[i] = xr.GetResourceReferences()
+		allRefs = append(allRefs, refsOf[i]...)
+	}
+	allMRs, allErrs := c.getManaged(c.reqCtx(ec), allRefs)
+
+	offset := 0
+	for i, xr := range xrs {
+		refs := refsOf[i]
+		end := offset + len(refs)
+		MRs, errs := allMRs[offset:end:end], allErrs[offset:end:end]
+		offset = end
+
+		XRs := []v12.ObjectReference{}
+		claims := []v12.ObjectReference{}
+		for j, mr := range MRs {
+			mrRef := refs[j]
+			if mr.GetName() != "" { // skip those not found
+				nameMatched, claimNameMatched := c.matchXR(xrds, &mrRef)
+				if nameMatched {
+					XRs = append(XRs, mrRef)
+				} else if claimNameMatched {
+					claims = append(claims, mrRef)
+				}
 			}
 		}
-
-		MRs = append(MRs, mr)
-	}
-	xr.Object["managedResources"] = MRs
-	xr.Object["managedResourcesXRs"] = XRs
-	xr.Object["managedResourcesClaims"] = claims
-	return nil
-}
-
-type FieldGetter = func(spec cpext.CompositeResourceDefinitionSpec) *string // pointer str to signal skip
-
-func (c *Controller) fillXRDList(ec echo.Context, list *unstructured.UnstructuredList, getKind FieldGetter) error {
-	xrds, err := c.cachedListXRDs(ec)
-	if err != nil {
-		return err
-	}
-
-	for _, xrd := range xrds.Items {
-		kind := getKind(xrd.Spec)
-		if kind == nil { // signal to skip it, has no corresponding kind name
-			continue
-		}
-
-		versionName := selectBestVersion(xrd.Spec.Versions)
-		if versionName == "" {
-			log.Warnf("No served version for XRD %s, skipping", xrd.Name)
-			continue
+		xr.Object["managedResources"] = MRs
+		xr.Object["managedResourcesXRs"] = XRs
+		xr.Object["managedResourcesClaims"] = claims
+		if warnings := fetchWarnings(refs, errs); len(warnings) > 0 {
+			xr.Object["warnings"] = warnings
 		}
-
-		gvk := schema.GroupVersionKind{
-			Group:   xrd.Spec.Group,
-			Version: versionName,
-			Kind:    *kind,
-		}
-		res, err := c.CRDs.List(c.ctx, gvk)
-		if err != nil {
-			log.Warnf("Failed to list resources for XRD %s: %v", xrd.Name, err)
-			continue
-		}
-
-		list.Items = append(list.Items, res.Items...)
 	}
 	return nil
 }
@@ -795,7 +881,10 @@ func (c *Controller) cachedListXRDs(ec echo.Context) (items *cpext.CompositeReso
 	cacheKey := "XRDs"
 	cached := ec.Get(cacheKey) // this would save couple of calls
 	if cached == nil {
-		items, err = c.XRDs.List(c.ctx)
+		items, err = c.XRDs.List(c.reqCtx(ec))
+		if forbidden(err, "XRDs") {
+			items, err = &cpext.CompositeResourceDefinitionList{}, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -807,19 +896,35 @@ func (c *Controller) cachedListXRDs(ec echo.Context) (items *cpext.CompositeReso
 	return items, nil
 }
 
-// selectBestVersion picks the best served version, preferring higher version names.
-func selectBestVersion(versions []cpext.CompositeResourceDefinitionVersion) string {
-	best := ""
-	for _, v := range versions {
-		if v.Served && v.Name > best {
-			best = v.Name
-		}
+func NewController(ctx context.Context, cfg *rest.Config, ns string, status *StatusInfo) (*Controller, error) {
+	cfg = crossplane.InstrumentConfig(cfg)
+
+	// the cache, discovery and access reviews work with komoplane's own account, the rest may impersonate the viewer
+	trk, err := tracker.New(ctx, cfg, ns, durationFromEnv("KP_SYNC_TIMEOUT", 30*time.Second))
+	if err != nil {
+		return nil, err
 	}
-	return best
-}
 
-func NewController(ctx context.Context, cfg *rest.Config, ns string, version string) (*Controller, error) {
-	_ = ns // TODO what's the use for namespace scope?
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	access := newAccessChecker(clientset.AuthorizationV1().SubjectAccessReviews())
+
+	mapper, err := crossplane.NewRESTMapper(cfg)
+	if err != nil {
+		return nil, err
+	}
+	trk.OnCRDsChanged(mapper.Reset)
+
+	if status.Impersonate {
+		cfg = crossplane.ImpersonateConfig(cfg)
+	}
+
+	kube, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	apiV1, err := crossplane.NewAPIv1Client(cfg)
 	if err != nil {
@@ -831,7 +936,7 @@ func NewController(ctx context.Context, cfg *rest.Config, ns string, version str
 		return nil, err
 	}
 
-	evt, err := crossplane.NewEventsClient(cfg)
+	evt, err := crossplane.NewEventsClient(cfg, ns)
 	if err != nil {
 		return nil, err
 	}
@@ -846,34 +951,24 @@ func NewController(ctx context.Context, cfg *rest.Config, ns string, version str
 		return nil, err
 	}
 
-	mrdCacheTTL := durationFromEnv("KP_MRD_CACHE_TTL", 5*time.Minute)
-	mrCacheTTL := durationFromEnv("KP_MR_CACHE_TTL", 1*time.Minute)
+	trk.Start()
 
 	controller := Controller{
-		ctx:    ctx,
-		APIv1:  apiV1,
-		ExtV1:  ext,
-		Events: evt,
-		apiExt: apiExt,
-		CRDs:   crossplane.NewVersionAwareCRDsClient(cfg, ext, versionAwareXRDs),
-		XRDs:   versionAwareXRDs,
-		StatusInfo: StatusInfo{
-			CurVer: version,
-		},
-
-		mrdCache: ttlcache.New(
-			ttlcache.WithTTL[bool, []*v1.CustomResourceDefinition](mrdCacheTTL),
-			ttlcache.WithDisableTouchOnHit[bool, []*v1.CustomResourceDefinition](),
-		),
-		mrCache: ttlcache.New(
-			ttlcache.WithTTL[bool, *unstructured.UnstructuredList](mrCacheTTL),
-			ttlcache.WithDisableTouchOnHit[bool, *unstructured.UnstructuredList](),
-		),
+		ctx:         ctx,
+		APIv1:       apiV1,
+		ExtV1:       ext,
+		Events:      evt,
+		apiExt:      apiExt,
+		CRDs:        crossplane.NewVersionAwareCRDsClient(cfg, ext, versionAwareXRDs, mapper),
+		XRDs:        versionAwareXRDs,
+		Tracker:     trk,
+		StatusInfo:  status,
+		namespace:   ns,
+		access:      access,
+		secrets:     kube.CoreV1(),
+		callTimeout: durationFromEnv("KP_CALL_TIMEOUT", 10*time.Second),
 	}
 
-	go controller.mrdCache.Start() // starts automatic expired item deletion
-	go controller.mrCache.Start()  // starts automatic expired item deletion
-
 	return &controller, nil
 }
 
@@ -886,22 +981,3 @@ func durationFromEnv(key string, durDefault time.Duration) time.Duration {
 	}
 	return dur
 }
-
-func getK8sConfig() (*rest.Config, error) {
-	config, err := rest.InClusterConfig()
-	if err == nil {
-		log.Infof("Using in-cluster Kubernetes connection")
-		return config, nil
-	}
-
-	log.Debugf("Failed to connect in-cluster: %v", err)
-
-	//kubeconfig := os.Getenv("KUBECONFIG")
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{
-		CurrentContext: os.Getenv("KUBECONTEXT"),
-	}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-
-	return kubeConfig.ClientConfig()
-}
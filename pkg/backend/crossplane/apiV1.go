@@ -2,6 +2,7 @@ package crossplane
 
 import (
 	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -9,26 +10,42 @@ import (
 
 type APIv1 interface {
 	Providers() ProviderInterface
-	// TODO: Configurations live here, if needed
+	ProviderRevisions() ProviderRevisionInterface
+	Functions() FunctionInterface
+	FunctionRevisions() FunctionRevisionInterface
+	Configurations() ConfigurationInterface
+	ConfigurationRevisions() ConfigurationRevisionInterface
+	Lock() LockInterface
 }
 
 type APIv1Client struct {
 	restClient rest.Interface
+	lockClient rest.Interface
+	packages   *packageClients
 }
 
 func NewAPIv1Client(c *rest.Config) (*APIv1Client, error) {
-	config := *c
-	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: v1.Group, Version: v1.Version}
-	config.APIPath = "/apis"
-	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
-	config.UserAgent = rest.DefaultKubernetesUserAgent()
+	client, err := restClientFor(c, schema.GroupVersion{Group: v1.Group, Version: v1.Version})
+	if err != nil {
+		return nil, err
+	}
 
-	client, err := rest.RESTClientFor(&config)
+	lock, err := restClientFor(c, v1beta1.SchemeGroupVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	return &APIv1Client{restClient: client}, nil
+	return &APIv1Client{restClient: client, lockClient: lock, packages: newPackageClients(c)}, nil
+}
+
+func restClientFor(c *rest.Config, version schema.GroupVersion) (rest.Interface, error) {
+	config := *c
+	config.ContentConfig.GroupVersion = &version
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	config.UserAgent = rest.DefaultKubernetesUserAgent()
+
+	return rest.RESTClientFor(&config)
 }
 
 func (c *APIv1Client) Providers() ProviderInterface {
@@ -36,3 +53,41 @@ func (c *APIv1Client) Providers() ProviderInterface {
 		restClient: c.restClient,
 	}
 }
+
+func (c *APIv1Client) ProviderRevisions() ProviderRevisionInterface {
+	return &providerRevisionClient{
+		restClient: c.restClient,
+	}
+}
+
+func (c *APIv1Client) Functions() FunctionInterface {
+	return &unstructuredPackageClient{
+		clients:  c.packages,
+		resource: "functions",
+	}
+}
+
+func (c *APIv1Client) FunctionRevisions() FunctionRevisionInterface {
+	return &unstructuredPackageClient{
+		clients:  c.packages,
+		resource: "functionrevisions",
+	}
+}
+
+func (c *APIv1Client) Configurations() ConfigurationInterface {
+	return &configurationClient{
+		restClient: c.restClient,
+	}
+}
+
+func (c *APIv1Client) ConfigurationRevisions() ConfigurationRevisionInterface {
+	return &configurationRevisionClient{
+		restClient: c.restClient,
+	}
+}
+
+func (c *APIv1Client) Lock() LockInterface {
+	return &lockClient{
+		restClient: c.lockClient,
+	}
+}
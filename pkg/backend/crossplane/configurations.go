@@ -0,0 +1,41 @@
+package crossplane
+
+import (
+	"context"
+
+	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/utils"
+	"k8s.io/client-go/rest"
+)
+
+type ConfigurationInterface interface {
+	List(ctx context.Context) (*v1.ConfigurationList, error)
+	Get(ctx context.Context, name string) (*v1.Configuration, error)
+}
+
+type configurationClient struct {
+	restClient rest.Interface
+}
+
+func (c *configurationClient) List(ctx context.Context) (*v1.ConfigurationList, error) {
+	result := v1.ConfigurationList{}
+	err := c.restClient.
+		Get().
+		Resource(utils.Plural(v1.ConfigurationKind)).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *configurationClient) Get(ctx context.Context, name string) (*v1.Configuration, error) {
+	result := v1.Configuration{}
+	err := c.restClient.
+		Get().
+		Resource(utils.Plural(v1.ConfigurationKind)).
+		Name(name).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
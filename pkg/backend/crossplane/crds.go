@@ -2,41 +2,73 @@ package crossplane
 
 import (
 	"context"
+	"sync"
+
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/komodorio/komoplane/pkg/backend/utils"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 )
 
 type CRDInterface interface {
 	List(ctx context.Context, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, error)
 	Get(ctx context.Context, dst resource.Object, reference *v1.ObjectReference) error // todo: migrate it onto ObjectReference
+	Patch(ctx context.Context, dst resource.Object, reference *v1.ObjectReference, mergePatch []byte) error
 }
 
 type crdClient struct {
-	cfg   *rest.Config
-	ExtV1 *ExtensionsV1Client
-	XRDs  XRDInterface
+	cfg    *rest.Config
+	ExtV1  *ExtensionsV1Client
+	XRDs   XRDInterface
+	mapper meta.RESTMapper // resolves plurals of any kind, optional
+
+	clientsMx sync.Mutex
+	clients   map[schema.GroupVersion]rest.Interface
 }
 
-func (c *crdClient) Get(ctx context.Context, result resource.Object, ref *v1.ObjectReference) error {
-	version := ref.GroupVersionKind().GroupVersion()
+// restClient returns the client shared by all the calls for the group version
+func (c *crdClient) restClient(version schema.GroupVersion) (rest.Interface, error) {
+	c.clientsMx.Lock()
+	defer c.clientsMx.Unlock()
+
+	if client, found := c.clients[version]; found {
+		return client, nil
+	}
+
 	config := *c.cfg
 	config.ContentConfig.GroupVersion = &version
 	config.APIPath = "/apis"
 	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
 	config.UserAgent = rest.DefaultKubernetesUserAgent()
 
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.clients == nil {
+		c.clients = map[schema.GroupVersion]rest.Interface{}
+	}
+	c.clients[version] = client
+	return client, nil
+}
+
+func (c *crdClient) Get(ctx context.Context, result resource.Object, ref *v1.ObjectReference) error {
 	plural, err := c.getPluralKind(ctx, ref)
 	if err != nil {
 		return err
 	}
 
-	client, err := rest.RESTClientFor(&config)
+	client, err := c.restClient(ref.GroupVersionKind().GroupVersion())
 	if err != nil {
 		return err
 	}
@@ -51,14 +83,28 @@ func (c *crdClient) Get(ctx context.Context, result resource.Object, ref *v1.Obj
 	return err
 }
 
-func (c *crdClient) List(ctx context.Context, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, error) {
-	config := *c.cfg
-	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: gvk.Group, Version: gvk.Version}
-	config.APIPath = "/apis"
-	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
-	config.UserAgent = rest.DefaultKubernetesUserAgent()
+func (c *crdClient) Patch(ctx context.Context, result resource.Object, ref *v1.ObjectReference, mergePatch []byte) error {
+	plural, err := c.getPluralKind(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	client, err := rest.RESTClientFor(&config)
+	client, err := c.restClient(ref.GroupVersionKind().GroupVersion())
+	if err != nil {
+		return err
+	}
+
+	return client.
+		Patch(types.MergePatchType).
+		NamespaceIfScoped(ref.Namespace, ref.Namespace != "").Name(ref.Name).
+		Resource(plural).
+		Body(mergePatch).
+		Do(ctx).
+		Into(result)
+}
+
+func (c *crdClient) List(ctx context.Context, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, error) {
+	client, err := c.restClient(gvk.GroupVersion())
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +120,17 @@ func (c *crdClient) List(ctx context.Context, gvk schema.GroupVersionKind) (*uns
 	return &result, err
 }
 
+// getPluralKind resolves the resource name of kind via discovery, then via XRDs, and guesses it as the last resort
 func (c *crdClient) getPluralKind(ctx context.Context, ref *v1.ObjectReference) (string, error) {
+	gvk := ref.GroupVersionKind()
+	if c.mapper != nil {
+		mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err == nil {
+			return mapping.Resource.Resource, nil
+		}
+		log.Debugf("Discovery has no resource for %s: %v", gvk, err)
+	}
+
 	var xrdClient XRDInterface
 	if c.XRDs != nil {
 		xrdClient = c.XRDs
@@ -108,10 +164,20 @@ func NewCRDsClient(cfg *rest.Config, ext *ExtensionsV1Client) CRDInterface {
 	}
 }
 
-func NewVersionAwareCRDsClient(cfg *rest.Config, ext *ExtensionsV1Client, xrds XRDInterface) CRDInterface {
+func NewVersionAwareCRDsClient(cfg *rest.Config, ext *ExtensionsV1Client, xrds XRDInterface, mapper meta.RESTMapper) CRDInterface {
 	return &crdClient{
-		cfg:   cfg,
-		ExtV1: ext,
-		XRDs:  xrds,
+		cfg:    cfg,
+		ExtV1:  ext,
+		XRDs:   xrds,
+		mapper: mapper,
+	}
+}
+
+// NewRESTMapper maps kinds onto resources using cached API discovery, it has to be Reset when CRDs change
+func NewRESTMapper(cfg *rest.Config) (meta.ResettableRESTMapper, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client)), nil
 }
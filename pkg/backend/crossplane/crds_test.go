@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
@@ -19,9 +20,11 @@ import (
 
 type mockXRDClient struct {
 	items []xpv1.CompositeResourceDefinition
+	lists int
 }
 
 func (m *mockXRDClient) List(_ context.Context) (*xpv1.CompositeResourceDefinitionList, error) {
+	m.lists++
 	return &xpv1.CompositeResourceDefinitionList{Items: m.items}, nil
 }
 
@@ -148,3 +151,89 @@ func TestCRDClient_List_ErrorHandling(t *testing.T) {
 	_, err := client.List(context.Background(), gvk)
 	assert.Error(t, err)
 }
+
+func TestCRDClient_Patch(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		assert.Contains(t, r.URL.Path, "namespaces/default/xapps/reference-data")
+
+		patch := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+		assert.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"crossplane.io/paused": "true"}}}, patch)
+
+		response := map[string]interface{}{
+			"apiVersion": "vpi.test.io/v1",
+			"kind":       "XApp",
+			"metadata": map[string]interface{}{
+				"name":        "reference-data",
+				"namespace":   "default",
+				"annotations": map[string]interface{}{"crossplane.io/paused": "true"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer testServer.Close()
+
+	xrds := &mockXRDClient{items: []xpv1.CompositeResourceDefinition{
+		{Spec: xpv1.CompositeResourceDefinitionSpec{
+			Group: "vpi.test.io",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XApp", Plural: "xapps"},
+		}},
+	}}
+	client := &crdClient{cfg: &rest.Config{Host: testServer.URL}, XRDs: xrds}
+
+	ref := &v1.ObjectReference{Name: "reference-data", Namespace: "default"}
+	ref.SetGroupVersionKind(schema.FromAPIVersionAndKind("vpi.test.io/v1", "XApp"))
+
+	result := &unstructured.Unstructured{}
+	err := client.Patch(context.Background(), result, ref, []byte(`{"metadata":{"annotations":{"crossplane.io/paused":"true"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "true", result.GetAnnotations()["crossplane.io/paused"])
+}
+
+func TestCRDClient_Get_Mapper(t *testing.T) {
+	paths := []string{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		response := map[string]interface{}{
+			"apiVersion": "search.example.org/v1",
+			"kind":       "Index",
+			"metadata":   map[string]interface{}{"name": "logs"},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer testServer.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "search.example.org", Version: "v1", Kind: "Index"},
+		schema.GroupVersionResource{Group: "search.example.org", Version: "v1", Resource: "indices"},
+		schema.GroupVersionResource{Group: "search.example.org", Version: "v1", Resource: "index"},
+		meta.RESTScopeRoot)
+
+	xrds := &mockXRDClient{items: []xpv1.CompositeResourceDefinition{
+		{Spec: xpv1.CompositeResourceDefinitionSpec{
+			Group: "vpi.test.io",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XApp", Plural: "xapps"},
+		}},
+	}}
+	client := &crdClient{cfg: &rest.Config{Host: testServer.URL}, XRDs: xrds, mapper: mapper}
+
+	for _, ref := range []v1.ObjectReference{
+		{APIVersion: "search.example.org/v1", Kind: "Index", Name: "logs"},
+		{APIVersion: "search.example.org/v1", Kind: "Index", Name: "logs"},
+		{APIVersion: "vpi.test.io/v1", Kind: "XApp", Name: "logs"},
+	} {
+		require.NoError(t, client.Get(context.Background(), &unstructured.Unstructured{}, &ref))
+	}
+
+	assert.Equal(t, []string{
+		"/apis/search.example.org/v1/indices/logs",
+		"/apis/search.example.org/v1/indices/logs",
+		"/apis/vpi.test.io/v1/xapps/logs",
+	}, paths, "irregular plural is resolved, unknown kinds fall back to XRDs")
+	assert.Equal(t, 1, xrds.lists, "XRDs are listed only for the kind unknown to discovery")
+	assert.Len(t, client.clients, 2, "one client per group version")
+}
@@ -10,12 +10,16 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+const eventSource = "komoplane"
+
 type EventsInterface interface {
 	List(ctx context.Context, reference *v1.ObjectReference) (*v1.EventList, error)
+	Create(ctx context.Context, reference *v1.ObjectReference, eventType string, reason string, message string) error
 }
 
 type eventsClient struct {
 	clientset *kubernetes.Clientset
+	namespace string // empty means all namespaces
 }
 
 func (c *eventsClient) List(ctx context.Context, reference *v1.ObjectReference) (*v1.EventList, error) {
@@ -32,11 +36,38 @@ func (c *eventsClient) List(ctx context.Context, reference *v1.ObjectReference)
 		options.FieldSelector += ",involvedObject.namespace=" + reference.Namespace
 	}
 
-	events, err := c.clientset.CoreV1().Events("").List(context.TODO(), options)
+	events, err := c.clientset.CoreV1().Events(c.namespace).List(ctx, options)
 	return events, err
 }
 
-func NewEventsClient(c *rest.Config) (EventsInterface, error) {
+func (c *eventsClient) Create(ctx context.Context, reference *v1.ObjectReference, eventType string, reason string, message string) error {
+	ns := reference.Namespace
+	if ns == "" {
+		ns = metav1.NamespaceDefault // that's where Kubernetes puts events of cluster-scoped objects
+	}
+
+	now := metav1.Now()
+	event := v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: reference.Name + ".",
+			Namespace:    ns,
+		},
+		InvolvedObject:      *reference,
+		Type:                eventType,
+		Reason:              reason,
+		Message:             message,
+		Source:              v1.EventSource{Component: eventSource},
+		ReportingController: eventSource,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+
+	_, err := c.clientset.CoreV1().Events(ns).Create(ctx, &event, metav1.CreateOptions{})
+	return err
+}
+
+func NewEventsClient(c *rest.Config, namespace string) (EventsInterface, error) {
 	clientset, err := kubernetes.NewForConfig(c)
 	if err != nil {
 		return nil, err
@@ -44,5 +75,6 @@ func NewEventsClient(c *rest.Config) (EventsInterface, error) {
 
 	return &eventsClient{
 		clientset: clientset,
+		namespace: namespace,
 	}, nil
 }
@@ -0,0 +1,120 @@
+package crossplane
+
+import (
+	"context"
+	"sync"
+
+	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const FunctionKind = "Function"
+
+// packageVersions are tried in order, as Functions appeared in v1beta1 and got promoted to v1 later.
+// The crossplane library we use predates Functions, so they are unstructured.
+var packageVersions = []string{v1.Version, "v1beta1"}
+
+type FunctionInterface interface {
+	List(ctx context.Context) (*unstructured.UnstructuredList, error)
+	Get(ctx context.Context, name string) (*unstructured.Unstructured, error)
+}
+
+type FunctionRevisionInterface interface {
+	List(ctx context.Context) (*unstructured.UnstructuredList, error)
+}
+
+type unstructuredPackageClient struct {
+	clients  *packageClients
+	resource string
+}
+
+func (c *unstructuredPackageClient) List(ctx context.Context) (*unstructured.UnstructuredList, error) {
+	result := unstructured.UnstructuredList{}
+	err := c.request(func(client rest.Interface) error {
+		return client.Get().Resource(c.resource).Do(ctx).Into(&result)
+	})
+	return &result, err
+}
+
+func (c *unstructuredPackageClient) Get(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	result := unstructured.Unstructured{}
+	err := c.request(func(client rest.Interface) error {
+		return client.Get().Resource(c.resource).Name(name).Do(ctx).Into(&result)
+	})
+	return &result, err
+}
+
+// request tries package API versions until one of them is served, starting from the one that served the resource last time
+func (c *unstructuredPackageClient) request(do func(client rest.Interface) error) error {
+	var err error
+	for _, version := range c.clients.versions(c.resource) {
+		client, cErr := c.clients.client(version)
+		if cErr != nil {
+			return cErr
+		}
+
+		err = do(client)
+		if err == nil {
+			c.clients.setServed(c.resource, version)
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// packageClients keeps one REST client per package API version, shared by all the requests
+type packageClients struct {
+	config  *rest.Config
+	mx      sync.Mutex
+	clients map[string]rest.Interface
+	served  map[string]string // resource to version
+}
+
+func newPackageClients(config *rest.Config) *packageClients {
+	return &packageClients{config: config, clients: map[string]rest.Interface{}, served: map[string]string{}}
+}
+
+func (p *packageClients) client(version string) (rest.Interface, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if client, found := p.clients[version]; found {
+		return client, nil
+	}
+
+	client, err := restClientFor(p.config, schema.GroupVersion{Group: v1.Group, Version: version})
+	if err != nil {
+		return nil, err
+	}
+	p.clients[version] = client
+	return client, nil
+}
+
+func (p *packageClients) versions(resource string) []string {
+	p.mx.Lock()
+	served, found := p.served[resource]
+	p.mx.Unlock()
+
+	if !found {
+		return packageVersions
+	}
+
+	res := []string{served}
+	for _, version := range packageVersions {
+		if version != served {
+			res = append(res, version)
+		}
+	}
+	return res
+}
+
+func (p *packageClients) setServed(resource string, version string) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.served[resource] = version
+}
@@ -0,0 +1,49 @@
+package crossplane
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+)
+
+func TestUnstructuredPackageClient_ServedVersion(t *testing.T) {
+	var paths []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/pkg.crossplane.io/v1beta1/functions":
+			_, _ = w.Write([]byte(`{"apiVersion": "pkg.crossplane.io/v1beta1", "kind": "FunctionList", "items": []}`))
+		case "/apis/pkg.crossplane.io/v1beta1/functions/function-patch-and-transform":
+			_, _ = w.Write([]byte(`{"apiVersion": "pkg.crossplane.io/v1beta1", "kind": "Function", "metadata": {"name": "function-patch-and-transform"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := NewAPIv1Client(&rest.Config{Host: testServer.URL})
+	require.NoError(t, err)
+
+	_, err = client.Functions().List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/apis/pkg.crossplane.io/v1/functions", "/apis/pkg.crossplane.io/v1beta1/functions"}, paths)
+
+	paths = nil
+	fn, err := client.Functions().Get(context.Background(), "function-patch-and-transform")
+	require.NoError(t, err)
+	assert.Equal(t, "function-patch-and-transform", fn.GetName())
+	assert.Equal(t, []string{"/apis/pkg.crossplane.io/v1beta1/functions/function-patch-and-transform"}, paths,
+		"the served version is remembered")
+
+	paths = nil
+	_, err = client.Functions().Get(context.Background(), "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Len(t, paths, 2, "other versions are still tried for missing ones")
+}
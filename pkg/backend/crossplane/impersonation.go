@@ -0,0 +1,55 @@
+package crossplane
+
+import (
+	"context"
+	"net/http"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+type impersonationKey struct{}
+
+// Impersonation is the identity that Kubernetes calls are made on behalf of
+type Impersonation struct {
+	User   string
+	Groups []string
+}
+
+// WithImpersonation makes the calls of clients from ImpersonateConfig to be done on behalf of the user
+func WithImpersonation(ctx context.Context, imp *Impersonation) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, imp)
+}
+
+func ImpersonationFrom(ctx context.Context) *Impersonation {
+	imp, _ := ctx.Value(impersonationKey{}).(*Impersonation)
+	return imp
+}
+
+// ImpersonateConfig makes all clients created from the config to impersonate the user from request context, if any
+func ImpersonateConfig(cfg *rest.Config) *rest.Config {
+	res := rest.CopyConfig(cfg)
+	res.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &impersonatingTransport{next: rt}
+	})
+	return res
+}
+
+type impersonatingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *impersonatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	imp := ImpersonationFrom(req.Context())
+	if imp == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(transport.ImpersonateUserHeader, imp.User)
+	req.Header.Del(transport.ImpersonateGroupHeader)
+	for _, group := range imp.Groups {
+		req.Header.Add(transport.ImpersonateGroupHeader, group)
+	}
+	return t.next.RoundTrip(req)
+}
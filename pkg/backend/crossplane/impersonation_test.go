@@ -0,0 +1,33 @@
+package crossplane
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+func TestImpersonateConfig(t *testing.T) {
+	var headers http.Header
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer testServer.Close()
+
+	client := &crdClient{cfg: ImpersonateConfig(&rest.Config{Host: testServer.URL})}
+	gvk := schema.GroupVersionKind{Group: "test.crossplane.io", Version: "v1", Kind: "things"}
+
+	_, _ = client.List(context.Background(), gvk)
+	assert.Empty(t, headers.Get(transport.ImpersonateUserHeader))
+
+	ctx := WithImpersonation(context.Background(), &Impersonation{User: "alice", Groups: []string{"devs", "ops"}})
+	_, _ = client.List(ctx, gvk)
+	assert.Equal(t, "alice", headers.Get(transport.ImpersonateUserHeader))
+	assert.Equal(t, []string{"devs", "ops"}, headers.Values(transport.ImpersonateGroupHeader))
+}
@@ -0,0 +1,31 @@
+package crossplane
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"k8s.io/client-go/rest"
+)
+
+// LockName is the name of the only Lock object, that Crossplane keeps the dependency information in
+const LockName = "lock"
+
+type LockInterface interface {
+	Get(ctx context.Context) (*v1beta1.Lock, error)
+}
+
+type lockClient struct {
+	restClient rest.Interface
+}
+
+func (c *lockClient) Get(ctx context.Context) (*v1beta1.Lock, error) {
+	result := v1beta1.Lock{}
+	err := c.restClient.
+		Get().
+		Resource("locks").
+		Name(LockName).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
@@ -0,0 +1,61 @@
+package crossplane
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	k8sRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "komoplane",
+		Name:      "kubernetes_requests_total",
+		Help:      "Number of requests made to Kubernetes API, by HTTP method and response code",
+	}, []string{"method", "code"})
+
+	k8sErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "komoplane",
+		Name:      "kubernetes_request_errors_total",
+		Help:      "Number of failed requests to Kubernetes API, including connection problems and error responses",
+	}, []string{"method"})
+)
+
+// RegisterMetrics adds the metrics of Kubernetes calls to registry
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{k8sRequests, k8sErrors} {
+		if err := reg.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InstrumentConfig makes all clients created from the config to count their calls
+func InstrumentConfig(cfg *rest.Config) *rest.Config {
+	res := rest.CopyConfig(cfg)
+	res.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &countingTransport{next: rt}
+	})
+	return res
+}
+
+type countingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		k8sRequests.WithLabelValues(req.Method, "error").Inc()
+		k8sErrors.WithLabelValues(req.Method).Inc()
+		return resp, err
+	}
+
+	k8sRequests.WithLabelValues(req.Method, strconv.Itoa(resp.StatusCode)).Inc()
+	if resp.StatusCode >= http.StatusBadRequest {
+		k8sErrors.WithLabelValues(req.Method).Inc()
+	}
+	return resp, nil
+}
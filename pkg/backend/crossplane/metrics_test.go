@@ -0,0 +1,30 @@
+package crossplane
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestInstrumentConfig(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer testServer.Close()
+
+	before := testutil.ToFloat64(k8sRequests.WithLabelValues("GET", "403"))
+	beforeErrs := testutil.ToFloat64(k8sErrors.WithLabelValues("GET"))
+
+	client := &crdClient{cfg: InstrumentConfig(&rest.Config{Host: testServer.URL})}
+	_, err := client.List(context.Background(), schema.GroupVersionKind{Group: "test.crossplane.io", Version: "v1", Kind: "things"})
+	assert.Error(t, err)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(k8sRequests.WithLabelValues("GET", "403")))
+	assert.Equal(t, beforeErrs+1, testutil.ToFloat64(k8sErrors.WithLabelValues("GET")))
+}
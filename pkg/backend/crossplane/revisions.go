@@ -0,0 +1,47 @@
+package crossplane
+
+import (
+	"context"
+
+	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/utils"
+	"k8s.io/client-go/rest"
+)
+
+type ProviderRevisionInterface interface {
+	List(ctx context.Context) (*v1.ProviderRevisionList, error)
+}
+
+type ConfigurationRevisionInterface interface {
+	List(ctx context.Context) (*v1.ConfigurationRevisionList, error)
+}
+
+type providerRevisionClient struct {
+	restClient rest.Interface
+}
+
+func (c *providerRevisionClient) List(ctx context.Context) (*v1.ProviderRevisionList, error) {
+	result := v1.ProviderRevisionList{}
+	err := c.restClient.
+		Get().
+		Resource(utils.Plural(v1.ProviderRevisionKind)).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+type configurationRevisionClient struct {
+	restClient rest.Interface
+}
+
+func (c *configurationRevisionClient) List(ctx context.Context) (*v1.ConfigurationRevisionList, error) {
+	result := v1.ConfigurationRevisionList{}
+	err := c.restClient.
+		Get().
+		Resource(utils.Plural(v1.ConfigurationRevisionKind)).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
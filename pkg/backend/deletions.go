@@ -0,0 +1,372 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	v12 "k8s.io/api/core/v1"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// finalizers that Crossplane and Kubernetes put onto claims, XRs and MRs, with the controllers that remove them
+var finalizerOwners = map[string]string{
+	finalizerManaged:                        "provider controller of the managed resource, after deleting the external resource",
+	"composite.apiextensions.crossplane.io": "Crossplane composite controller, after composed resources are gone",
+	"finalizer.apiextensions.crossplane.io": "Crossplane claim controller, after the composite resource is gone",
+	finalizerUsage:                          "Crossplane usage controller, after no Usage references the object",
+	metav1.FinalizerDeleteDependents:        "Kubernetes garbage collector, after dependents with blockOwnerDeletion are gone",
+	metav1.FinalizerOrphanDependents:        "Kubernetes garbage collector, after orphaning dependents",
+}
+
+const (
+	finalizerManaged = "finalizer.managedresource.crossplane.io"
+	finalizerUsage   = "in-use.crossplane.io"
+)
+
+const (
+	providerInstalled    = "Installed"
+	providerNotInstalled = "NotInstalled" // the CRD is left behind by a provider that is gone
+	providerUnknown      = "Unknown"      // providers are hidden by RBAC, or the CRD was not installed by a provider
+)
+
+// DeletionReport lists objects stuck in deletion, with the kinds that could not be checked
+type DeletionReport struct {
+	Items     []*DeletionInfo `json:"items"`
+	Forbidden []string        `json:"forbidden,omitempty"`
+}
+
+// DeletionInfo explains why an object in Terminating state is not gone yet
+type DeletionInfo struct {
+	ObjectRef
+	Class          string          `json:"class"`
+	DeletingSince  metav1.Time     `json:"deletingSince"`
+	Duration       string          `json:"duration"`
+	DeletionPolicy string          `json:"deletionPolicy,omitempty"`
+	Finalizers     []FinalizerInfo `json:"finalizers"`
+	Children       []ChildStatus   `json:"children"`                // composed resources of XR, or XR of claim
+	ProviderState  string          `json:"providerState,omitempty"` // for managed resources only
+	Provider       *ProviderHealth `json:"provider,omitempty"`
+	BlockedBy      []string        `json:"blockedBy"`
+}
+
+type FinalizerInfo struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner"` // the controller expected to remove the finalizer
+	Known bool   `json:"known"`
+}
+
+type ChildStatus struct {
+	ObjectRef
+	Exists   bool `json:"exists"`
+	Deleting bool `json:"deleting"`
+}
+
+type ProviderHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Problem string `json:"problem,omitempty"`
+}
+
+func (c *Controller) GetDeletions(ec echo.Context) error {
+	ctx := c.reqCtx(ec)
+	classes := []tracker.Class{tracker.ClassClaim, tracker.ClassComposite, tracker.ClassManaged}
+	objects := map[tracker.Class][]unstructured.Unstructured{}
+	res := DeletionReport{Items: []*DeletionInfo{}}
+	for _, class := range classes {
+		var forbiddenKinds []string
+		objects[class], forbiddenKinds = c.listVisible(ec, class)
+		res.Forbidden = append(res.Forbidden, forbiddenKinds...)
+	}
+	sort.Strings(res.Forbidden)
+
+	deleting := false
+	for _, items := range objects {
+		for i := range items {
+			if items[i].GetDeletionTimestamp() != nil {
+				deleting = true
+			}
+		}
+	}
+
+	if !deleting {
+		return ec.JSONPretty(http.StatusOK, res, "  ")
+	}
+
+	// provider details are needed only when something is being deleted
+	providers, err := c.providersByKind(ctx)
+	if err != nil {
+		return err
+	}
+
+	health, err := c.providersHealth(ec)
+	if err != nil {
+		return err
+	}
+
+	var uninstalled map[schema.GroupKind]bool
+	if health == nil {
+		res.Forbidden = append(res.Forbidden, cpv1.ProviderKind)
+	} else {
+		uninstalled = uninstalledKinds(c.Tracker.CRDs(ctx), providers)
+	}
+
+	analyzer := newDeletionAnalyzer(objects, providers, uninstalled, health)
+	now := time.Now()
+	for _, class := range classes {
+		for i := range objects[class] {
+			if info := analyzer.analyze(&objects[class][i], class, now); info != nil {
+				res.Items = append(res.Items, info)
+			}
+		}
+	}
+
+	sort.SliceStable(res.Items, func(i, j int) bool {
+		return res.Items[i].DeletingSince.Before(&res.Items[j].DeletingSince)
+	})
+
+	return ec.JSONPretty(http.StatusOK, res, "  ")
+}
+
+// providersHealth returns nil map when the viewer may not list providers
+func (c *Controller) providersHealth(ec echo.Context) (map[string]*ProviderHealth, error) {
+	providers, err := c.APIv1.Providers().List(c.reqCtx(ec))
+	if forbidden(err, "providers") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*ProviderHealth{}
+	for i := range providers.Items {
+		obj, err := toUnstructured(&providers.Items[i], cpv1.ProviderGroupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+
+		health := &ProviderHealth{Name: obj.GetName(), Healthy: true}
+		for _, typ := range []xpv1.ConditionType{cpv1.TypeInstalled, cpv1.TypeHealthy} {
+			if issue := conditionIssue(obj, typ); issue != nil {
+				health.Healthy = false
+				health.Problem = issue.Problem
+				break
+			}
+		}
+		res[health.Name] = health
+	}
+	return res, nil
+}
+
+// uninstalledKinds finds kinds with CRDs owned by a provider that is not in the list anymore
+func uninstalledKinds(crds []*v1.CustomResourceDefinition, providers map[schema.GroupKind]string) map[schema.GroupKind]bool {
+	res := map[schema.GroupKind]bool{}
+	for _, crd := range crds {
+		gk := schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+		if _, found := providers[gk]; found {
+			continue
+		}
+		for _, ref := range crd.OwnerReferences {
+			if ref.Kind == cpv1.ProviderKind && ref.APIVersion == cpv1.Group+"/"+cpv1.Version || ref.Kind == cpv1.ProviderRevisionKind {
+				res[gk] = true
+			}
+		}
+	}
+	return res
+}
+
+type deletionAnalyzer struct {
+	byKey       map[string]*unstructured.Unstructured
+	byOwner     map[types.UID][]*unstructured.Unstructured
+	providers   map[schema.GroupKind]string
+	uninstalled map[schema.GroupKind]bool
+	health      map[string]*ProviderHealth
+}
+
+func newDeletionAnalyzer(objects map[tracker.Class][]unstructured.Unstructured, providers map[schema.GroupKind]string,
+	uninstalled map[schema.GroupKind]bool, health map[string]*ProviderHealth) *deletionAnalyzer {
+	res := &deletionAnalyzer{
+		byKey:       map[string]*unstructured.Unstructured{},
+		byOwner:     map[types.UID][]*unstructured.Unstructured{},
+		providers:   providers,
+		uninstalled: uninstalled,
+		health:      health,
+	}
+
+	for _, items := range objects {
+		for i := range items {
+			obj := &items[i]
+			res.byKey[refKey(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())] = obj
+			for _, owner := range obj.GetOwnerReferences() {
+				res.byOwner[owner.UID] = append(res.byOwner[owner.UID], obj)
+			}
+		}
+	}
+	return res
+}
+
+// analyze returns nil for objects that are not being deleted
+func (a *deletionAnalyzer) analyze(obj *unstructured.Unstructured, class tracker.Class, now time.Time) *DeletionInfo {
+	deleted := obj.GetDeletionTimestamp()
+	if deleted == nil {
+		return nil
+	}
+
+	res := &DeletionInfo{
+		ObjectRef:     ObjectRef{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()},
+		Class:         string(class),
+		DeletingSince: *deleted,
+		Duration:      duration.HumanDuration(now.Sub(deleted.Time)),
+		Finalizers:    []FinalizerInfo{},
+		Children:      a.children(obj),
+		BlockedBy:     []string{},
+	}
+	res.DeletionPolicy, _, _ = unstructured.NestedString(obj.Object, "spec", "deletionPolicy")
+
+	for _, name := range obj.GetFinalizers() {
+		owner, known := finalizerOwners[name]
+		if !known {
+			owner = "unknown controller, it might have been uninstalled"
+		}
+		res.Finalizers = append(res.Finalizers, FinalizerInfo{Name: name, Owner: owner, Known: known})
+	}
+
+	if class == tracker.ClassManaged {
+		res.ProviderState, res.Provider = a.provider(obj.GroupVersionKind().GroupKind())
+	}
+
+	res.BlockedBy = blockedBy(obj, res)
+	return res
+}
+
+// provider tells "not installed" only when it is certain, hidden or unrelated providers are unknown
+func (a *deletionAnalyzer) provider(gk schema.GroupKind) (string, *ProviderHealth) {
+	if health := a.health[a.providers[gk]]; health != nil {
+		return providerInstalled, health
+	}
+	if a.uninstalled[gk] {
+		return providerNotInstalled, nil
+	}
+	return providerUnknown, nil
+}
+
+// children are found both by references in spec and by owner references, as either might be missing
+func (a *deletionAnalyzer) children(obj *unstructured.Unstructured) []ChildStatus {
+	refs := []v12.ObjectReference{}
+	if ref, found, _ := unstructured.NestedMap(obj.Object, "spec", "resourceRef"); found {
+		refs = append(refs, objectReference(ref))
+	}
+
+	list, _, _ := unstructured.NestedSlice(obj.Object, "spec", "resourceRefs")
+	for _, item := range list {
+		if ref, ok := item.(map[string]interface{}); ok {
+			refs = append(refs, objectReference(ref))
+		}
+	}
+
+	res := []ChildStatus{}
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		key := refKey(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		child, exists := a.byKey[key]
+		res = append(res, ChildStatus{
+			ObjectRef: ObjectRef{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name},
+			Exists:    exists,
+			Deleting:  exists && child.GetDeletionTimestamp() != nil,
+		})
+	}
+
+	for _, child := range a.byOwner[obj.GetUID()] {
+		key := refKey(child.GetAPIVersion(), child.GetKind(), child.GetNamespace(), child.GetName())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		res = append(res, ChildStatus{
+			ObjectRef: ObjectRef{APIVersion: child.GetAPIVersion(), Kind: child.GetKind(), Namespace: child.GetNamespace(), Name: child.GetName()},
+			Exists:    true,
+			Deleting:  child.GetDeletionTimestamp() != nil,
+		})
+	}
+	return res
+}
+
+func objectReference(ref map[string]interface{}) v12.ObjectReference {
+	res := v12.ObjectReference{}
+	res.APIVersion, _, _ = unstructured.NestedString(ref, "apiVersion")
+	res.Kind, _, _ = unstructured.NestedString(ref, "kind")
+	res.Namespace, _, _ = unstructured.NestedString(ref, "namespace")
+	res.Name, _, _ = unstructured.NestedString(ref, "name")
+	return res
+}
+
+// refKey ignores the version, as references might use other version than the one being watched
+func refKey(apiVersion string, kind string, namespace string, name string) string {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+	return gv.Group + "/" + kind + "/" + namespace + "/" + name
+}
+
+// blockedBy lists human-readable reasons why the finalizers are not removed yet
+func blockedBy(obj *unstructured.Unstructured, info *DeletionInfo) []string {
+	res := []string{}
+
+	existing := 0
+	for _, child := range info.Children {
+		if child.Exists {
+			existing++
+		}
+	}
+	if existing > 0 {
+		res = append(res, fmt.Sprintf("%d of %d child resources still exist", existing, len(info.Children)))
+	}
+
+	for _, finalizer := range info.Finalizers {
+		switch {
+		case !finalizer.Known:
+			res = append(res, "finalizer "+finalizer.Name+" is not managed by Crossplane, the controller owning it must remove it")
+		case finalizer.Name == finalizerUsage:
+			res = append(res, "object is protected by a Usage")
+		case finalizer.Name == finalizerManaged:
+			res = append(res, managedBlockers(obj, info)...)
+		}
+	}
+
+	if len(info.Finalizers) == 0 {
+		res = append(res, "no finalizers left, the object should be gone shortly")
+	}
+	return res
+}
+
+func managedBlockers(obj *unstructured.Unstructured, info *DeletionInfo) []string {
+	res := []string{}
+	switch {
+	case info.ProviderState == providerNotInstalled:
+		res = append(res, "provider that installed "+obj.GetKind()+" is not installed anymore, nothing will remove the finalizer")
+	case info.ProviderState == providerUnknown:
+		res = append(res, "can't tell which provider serves "+obj.GetKind()+", check that its controller is running")
+	case !info.Provider.Healthy:
+		res = append(res, "provider "+info.Provider.Name+" is unhealthy: "+info.Provider.Problem)
+	}
+
+	if synced := conditionSummary(obj, xpv1.TypeSynced); synced != nil && synced.Status == string(v12.ConditionFalse) {
+		res = append(res, "provider fails to delete the external resource: "+strings.TrimPrefix(conditionProblem(synced, string(xpv1.TypeSynced)), "not Synced: "))
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func deletingObj(kind string, name string, since time.Time, finalizers ...string) *unstructured.Unstructured {
+	obj := diagObj(kind, name)
+	obj.SetUID(types.UID("uid-" + name))
+	if !since.IsZero() {
+		obj.SetDeletionTimestamp(&metav1.Time{Time: since})
+	}
+	obj.SetFinalizers(finalizers)
+	return obj
+}
+
+func TestDeletionAnalyzer(t *testing.T) {
+	now := time.Now()
+	xr := deletingObj("XBucket", "xr", now.Add(-time.Hour), "composite.apiextensions.crossplane.io")
+	xr.Object["spec"] = map[string]interface{}{"resourceRefs": []interface{}{
+		map[string]interface{}{"apiVersion": "example.org/v1beta1", "kind": "Bucket", "name": "bucket"},
+		map[string]interface{}{"apiVersion": "example.org/v1", "kind": "Bucket", "name": "gone"},
+	}}
+
+	bucket := deletingObj("Bucket", "bucket", now.Add(-time.Hour), finalizerManaged)
+	bucket.Object["status"] = map[string]interface{}{"conditions": []interface{}{cond("Synced", "False", "ReconcileError")}}
+	bucket.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.org/v1", Kind: "XBucket", Name: "xr", UID: "uid-xr"}})
+
+	policy := deletingObj("Policy", "policy", time.Time{})
+	policy.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.org/v1", Kind: "XBucket", Name: "xr", UID: "uid-xr"}})
+
+	orphan := deletingObj("Topic", "orphan", now.Add(-time.Minute), finalizerManaged, "example.com/cleanup")
+	queue := deletingObj("Queue", "queue", now.Add(-time.Minute), finalizerManaged)
+
+	objects := map[tracker.Class][]unstructured.Unstructured{
+		tracker.ClassComposite: {*xr},
+		tracker.ClassManaged:   {*bucket, *policy, *orphan, *queue},
+	}
+	providers := map[schema.GroupKind]string{{Group: "example.org", Kind: "Bucket"}: "provider-example"}
+	uninstalled := map[schema.GroupKind]bool{{Group: "example.org", Kind: "Queue"}: true}
+	health := map[string]*ProviderHealth{"provider-example": {Name: "provider-example", Problem: "not Healthy: UnhealthyPackageRevision"}}
+	analyzer := newDeletionAnalyzer(objects, providers, uninstalled, health)
+
+	assert.Nil(t, analyzer.analyze(policy, tracker.ClassManaged, now))
+
+	info := analyzer.analyze(xr, tracker.ClassComposite, now)
+	require.NotNil(t, info)
+	assert.Equal(t, "60m", info.Duration)
+	require.Len(t, info.Finalizers, 1)
+	assert.True(t, info.Finalizers[0].Known)
+	require.Len(t, info.Children, 3, "refs by other version are matched, owned ones are added")
+	assert.True(t, info.Children[0].Exists)
+	assert.True(t, info.Children[0].Deleting)
+	assert.False(t, info.Children[1].Exists)
+	assert.Equal(t, "policy", info.Children[2].Name)
+	assert.False(t, info.Children[2].Deleting)
+	assert.Equal(t, []string{"2 of 3 child resources still exist"}, info.BlockedBy)
+
+	info = analyzer.analyze(bucket, tracker.ClassManaged, now)
+	require.NotNil(t, info)
+	require.NotNil(t, info.Provider)
+	assert.Equal(t, providerInstalled, info.ProviderState)
+	assert.Empty(t, info.Children)
+	assert.Equal(t, []string{
+		"provider provider-example is unhealthy: not Healthy: UnhealthyPackageRevision",
+		"provider fails to delete the external resource: ReconcileError, ReconcileError message",
+	}, info.BlockedBy)
+
+	info = analyzer.analyze(orphan, tracker.ClassManaged, now)
+	require.NotNil(t, info)
+	require.Len(t, info.Finalizers, 2)
+	assert.False(t, info.Finalizers[1].Known)
+	assert.Equal(t, []string{
+		"can't tell which provider serves Topic, check that its controller is running",
+		"finalizer example.com/cleanup is not managed by Crossplane, the controller owning it must remove it",
+	}, info.BlockedBy)
+
+	info = analyzer.analyze(queue, tracker.ClassManaged, now)
+	require.NotNil(t, info)
+	assert.Equal(t, providerNotInstalled, info.ProviderState)
+	assert.Equal(t, []string{"provider that installed Queue is not installed anymore, nothing will remove the finalizer"}, info.BlockedBy)
+
+	hidden := newDeletionAnalyzer(objects, map[schema.GroupKind]string{}, nil, nil)
+	info = hidden.analyze(bucket, tracker.ClassManaged, now)
+	require.NotNil(t, info)
+	assert.Equal(t, providerUnknown, info.ProviderState, "providers are forbidden")
+	assert.Nil(t, info.Provider)
+}
+
+func TestUninstalledKinds(t *testing.T) {
+	crd := func(kind string, owners ...metav1.OwnerReference) *v1.CustomResourceDefinition {
+		res := &v1.CustomResourceDefinition{Spec: v1.CustomResourceDefinitionSpec{Group: "example.org", Names: v1.CustomResourceDefinitionNames{Kind: kind}}}
+		res.OwnerReferences = owners
+		return res
+	}
+	crds := []*v1.CustomResourceDefinition{
+		crd("Bucket", metav1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "Provider", Name: "provider-example"}),
+		crd("Queue", metav1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "ProviderRevision", Name: "provider-gone-abc"}),
+		crd("Topic"),
+	}
+	providers := map[schema.GroupKind]string{{Group: "example.org", Kind: "Bucket"}: "provider-example"}
+	assert.Equal(t, map[schema.GroupKind]bool{{Group: "example.org", Kind: "Queue"}: true}, uninstalledKinds(crds, providers))
+}
@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/labstack/echo/v4"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DependencyGraph is built from Crossplane Lock, nodes are identified by package source
+type DependencyGraph struct {
+	Packages     []LockedPackage     `json:"packages"`
+	Dependencies []PackageDependency `json:"dependencies"`
+	Unsatisfied  []PackageDependency `json:"unsatisfied"`
+}
+
+type LockedPackage struct {
+	Source     string   `json:"source"`
+	Revision   string   `json:"revision"`
+	Type       string   `json:"type"`
+	Version    string   `json:"version"`
+	Direct     bool     `json:"direct"`     // nothing depends on it, so it was installed on its own
+	RequiredBy []string `json:"requiredBy"` // sources of dependent packages
+}
+
+type PackageDependency struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Type        string `json:"type,omitempty"`
+	Constraints string `json:"constraints"`
+	Resolved    string `json:"resolved,omitempty"` // version in the lock, empty if not installed
+	Satisfied   bool   `json:"satisfied"`
+	Problem     string `json:"problem,omitempty"`
+}
+
+func (c *Controller) GetDependencies(ec echo.Context) error {
+	lock, err := c.APIv1.Lock().Get(c.reqCtx(ec))
+	if forbidden(err, "lock") || k8sErrors.IsNotFound(err) { // no lock until the first package is installed
+		lock, err = &v1beta1.Lock{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	graph := buildDependencyGraph(lock.Packages)
+	if pkg := ec.QueryParam("package"); pkg != "" {
+		graph = graph.around(pkg)
+	}
+
+	return ec.JSONPretty(http.StatusOK, graph, "  ")
+}
+
+func buildDependencyGraph(packages []v1beta1.LockPackage) *DependencyGraph {
+	res := &DependencyGraph{
+		Packages:     []LockedPackage{},
+		Dependencies: []PackageDependency{},
+		Unsatisfied:  []PackageDependency{},
+	}
+
+	bySource := map[string]*v1beta1.LockPackage{}
+	for i := range packages {
+		bySource[packages[i].Source] = &packages[i]
+	}
+
+	requiredBy := map[string][]string{}
+	for _, pkg := range packages {
+		for _, dep := range pkg.Dependencies {
+			edge := PackageDependency{
+				From:        pkg.Source,
+				To:          dep.Package,
+				Type:        string(dep.Type),
+				Constraints: dep.Constraints,
+			}
+			requiredBy[dep.Package] = append(requiredBy[dep.Package], pkg.Source)
+
+			if locked, found := bySource[dep.Package]; !found {
+				edge.Problem = "dependency is not installed"
+			} else {
+				edge.Resolved = locked.Version
+				satisfied, err := matchesConstraints(dep.Constraints, locked.Version)
+				switch {
+				case err != nil:
+					edge.Problem = "failed to check constraints: " + err.Error()
+				case !satisfied:
+					edge.Problem = "installed version " + locked.Version + " does not satisfy " + dep.Constraints
+				default:
+					edge.Satisfied = true
+				}
+			}
+
+			res.Dependencies = append(res.Dependencies, edge)
+			if !edge.Satisfied {
+				res.Unsatisfied = append(res.Unsatisfied, edge)
+			}
+		}
+	}
+
+	for _, pkg := range packages {
+		deps := requiredBy[pkg.Source]
+		sort.Strings(deps)
+		if deps == nil {
+			deps = []string{}
+		}
+
+		res.Packages = append(res.Packages, LockedPackage{
+			Source:     pkg.Source,
+			Revision:   pkg.Name,
+			Type:       string(pkg.Type),
+			Version:    pkg.Version,
+			Direct:     len(deps) == 0,
+			RequiredBy: deps,
+		})
+	}
+
+	sort.Slice(res.Packages, func(i, j int) bool {
+		return res.Packages[i].Source < res.Packages[j].Source
+	})
+	return res
+}
+
+// around leaves only the package, the packages that require it directly or indirectly, and its own dependencies.
+// That answers the question "why is this package installed".
+func (g *DependencyGraph) around(pkg string) *DependencyGraph {
+	start := pkg
+	for _, p := range g.Packages {
+		if p.Revision == pkg { // allow looking up by revision name too
+			start = p.Source
+		}
+	}
+
+	up := map[string][]string{}
+	down := map[string][]string{}
+	for _, dep := range g.Dependencies {
+		up[dep.To] = append(up[dep.To], dep.From)
+		down[dep.From] = append(down[dep.From], dep.To)
+	}
+
+	keep := map[string]bool{}
+	for _, edges := range []map[string][]string{up, down} {
+		queue := []string{start}
+		seen := map[string]bool{start: true}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			keep[cur] = true
+			for _, next := range edges[cur] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	res := &DependencyGraph{
+		Packages:     []LockedPackage{},
+		Dependencies: []PackageDependency{},
+		Unsatisfied:  []PackageDependency{},
+	}
+	for _, p := range g.Packages {
+		if keep[p.Source] {
+			res.Packages = append(res.Packages, p)
+		}
+	}
+	for _, dep := range g.Dependencies {
+		if keep[dep.From] && keep[dep.To] {
+			res.Dependencies = append(res.Dependencies, dep)
+			if !dep.Satisfied {
+				res.Unsatisfied = append(res.Unsatisfied, dep)
+			}
+		}
+	}
+	return res
+}
+
+// matchesConstraints checks the version the same way Crossplane does when resolving dependencies
+func matchesConstraints(constraints string, version string) (bool, error) {
+	c, err := semver.NewConstraint(constraints)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
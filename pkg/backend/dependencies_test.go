@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lockPackage(source string, version string, deps ...v1beta1.Dependency) v1beta1.LockPackage {
+	return v1beta1.LockPackage{
+		Name:         source + "-abc",
+		Type:         v1beta1.ProviderPackageType,
+		Source:       source,
+		Version:      version,
+		Dependencies: deps,
+	}
+}
+
+func dependency(pkg string, constraints string) v1beta1.Dependency {
+	return v1beta1.Dependency{Package: pkg, Type: v1beta1.ProviderPackageType, Constraints: constraints}
+}
+
+func testLock() []v1beta1.LockPackage {
+	platform := lockPackage("org/platform", "v2.0.0",
+		dependency("org/networking", ">=v1.0.0"),
+		dependency("upbound/provider-aws-s3", ">=v1.0.0"),
+	)
+	platform.Type = v1beta1.ConfigurationPackageType
+
+	networking := lockPackage("org/networking", "v1.1.0",
+		dependency("upbound/provider-aws-ec2", "^v0.47"),
+		dependency("upbound/provider-helm", ">=v0.15.0"),
+	)
+	networking.Type = v1beta1.ConfigurationPackageType
+
+	return []v1beta1.LockPackage{
+		platform,
+		networking,
+		lockPackage("upbound/provider-aws-s3", "v1.2.0"),
+		lockPackage("upbound/provider-aws-ec2", "v1.0.0"),
+		lockPackage("other/provider-k8s", "v0.9.0"),
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	graph := buildDependencyGraph(testLock())
+
+	require.Len(t, graph.Packages, 5)
+	assert.Equal(t, "org/networking", graph.Packages[0].Source, "sorted by source")
+	assert.Equal(t, []string{"org/platform"}, graph.Packages[0].RequiredBy)
+	assert.False(t, graph.Packages[0].Direct)
+	assert.True(t, graph.Packages[1].Direct)
+	assert.Equal(t, "other/provider-k8s", graph.Packages[2].Source)
+	assert.True(t, graph.Packages[2].Direct)
+
+	assert.Len(t, graph.Dependencies, 4)
+	require.Len(t, graph.Unsatisfied, 2)
+
+	assert.Equal(t, "upbound/provider-aws-ec2", graph.Unsatisfied[0].To)
+	assert.Equal(t, "v1.0.0", graph.Unsatisfied[0].Resolved)
+	assert.Contains(t, graph.Unsatisfied[0].Problem, "does not satisfy ^v0.47")
+
+	assert.Equal(t, "upbound/provider-helm", graph.Unsatisfied[1].To)
+	assert.Empty(t, graph.Unsatisfied[1].Resolved)
+	assert.Equal(t, "dependency is not installed", graph.Unsatisfied[1].Problem)
+}
+
+func TestDependencyGraph_Around(t *testing.T) {
+	graph := buildDependencyGraph(testLock())
+
+	res := graph.around("upbound/provider-aws-ec2-abc") // by revision name
+	sources := []string{}
+	for _, p := range res.Packages {
+		sources = append(sources, p.Source)
+	}
+	assert.Equal(t, []string{"org/networking", "org/platform", "upbound/provider-aws-ec2"}, sources)
+	assert.Len(t, res.Dependencies, 2)
+	assert.Len(t, res.Unsatisfied, 1)
+
+	res = graph.around("org/networking")
+	assert.Len(t, res.Packages, 3, "the platform, networking itself and its installed dependency")
+	assert.Len(t, res.Dependencies, 3)
+}
+
+func TestMatchesConstraints(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		out         bool
+	}{
+		{">=v1.0.0", "v1.2.3", true},
+		{"^v0.47", "v1.0.0", false},
+		{">=1.0.0", "v1.3.0-rc.1", false}, // pre-releases are excluded, as Crossplane does
+		{">=v1.3.0-rc.9", "v1.3.0-rc.10", true},
+	}
+
+	for _, tt := range tests {
+		satisfied, err := matchesConstraints(tt.constraints, tt.version)
+		require.NoError(t, err)
+		assert.Equal(t, tt.out, satisfied, tt.constraints+" "+tt.version)
+	}
+
+	_, err := matchesConstraints("not a constraint", "v1.0.0")
+	assert.Error(t, err)
+}
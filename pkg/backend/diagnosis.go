@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"context"
+	"sort"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	maxDiagnosisDepth = 10
+	typeFound         = "Found" // set by getDynamicResource for resources that failed to load
+)
+
+// RootCause is an unhealthy resource that has no unhealthy resources below it in composition tree
+type RootCause struct {
+	ObjectRef
+	Depth     int      `json:"depth"`
+	Condition string   `json:"condition"`
+	Reason    string   `json:"reason,omitempty"`
+	Message   string   `json:"message,omitempty"`
+	Events    []string `json:"events,omitempty"` // messages of warning events
+}
+
+type diagNode struct {
+	obj      *unstructured.Unstructured
+	depth    int
+	events   []string
+	children []*diagNode
+}
+
+// diagnose walks XR tree down to MRs, looking for the deepest unhealthy resources.
+// Events that failed to load are returned as warnings.
+func (c *Controller) diagnose(ec echo.Context, xr *uxres.Unstructured, depth int) ([]RootCause, []Warning) {
+	root := c.buildDiagTree(ec, xr, depth, map[string]bool{})
+	warnings := c.fillWarningEvents(c.reqCtx(ec), root.flatten(nil))
+	return findRootCauses(root), warnings
+}
+
+func (c *Controller) buildDiagTree(ec echo.Context, xr *uxres.Unstructured, depth int, visited map[string]bool) *diagNode {
+	node := &diagNode{obj: &xr.Unstructured, depth: depth}
+	visited[objKey(&xr.Unstructured)] = true
+
+	MRs, _ := xr.Object["managedResources"].([]*ManagedUnstructured)
+	nestedXRs, _ := xr.Object["managedResourcesXRs"].([]v12.ObjectReference)
+	for _, mr := range MRs {
+		if visited[objKey(&mr.Unstructured.Unstructured)] {
+			log.Debugf("Skipping already visited %s %s", mr.GetKind(), mr.GetName())
+			continue
+		}
+
+		if depth < maxDiagnosisDepth && isNestedXR(mr, nestedXRs) {
+			nested := uxres.New()
+			if _, expanded := mr.Object["managedResources"]; expanded { // already loaded for depth parameter
+				nested.Object = mr.Unstructured.Unstructured.Object
+			} else {
+				nested.Object = mr.Unstructured.Unstructured.DeepCopy().Object // not to pollute the response with nested data
+				if err := c.fillManagedResources(ec, nested); err != nil {
+					log.Warnf("Failed to get resources of nested XR %s: %v", nested.GetName(), err)
+				}
+			}
+			node.children = append(node.children, c.buildDiagTree(ec, nested, depth+1, visited))
+			continue
+		}
+
+		child := &diagNode{obj: &mr.Unstructured.Unstructured, depth: depth + 1}
+		visited[objKey(child.obj)] = true
+		node.children = append(node.children, child)
+	}
+
+	return node
+}
+
+func (n *diagNode) flatten(res []*diagNode) []*diagNode {
+	res = append(res, n)
+	for _, child := range n.children {
+		res = child.flatten(res)
+	}
+	return res
+}
+
+// fillWarningEvents only looks at resources that are not Ready, to save API calls
+func (c *Controller) fillWarningEvents(ctx context.Context, nodes []*diagNode) []Warning {
+	refs := []v12.ObjectReference{}
+	pending := []*diagNode{}
+	for _, node := range nodes {
+		if conditionStatus(node.obj, xpv1.TypeReady) == string(v12.ConditionTrue) || node.obj.GetName() == "" {
+			continue
+		}
+
+		ref := v12.ObjectReference{Namespace: node.obj.GetNamespace(), Name: node.obj.GetName()}
+		ref.SetGroupVersionKind(node.obj.GroupVersionKind())
+		refs = append(refs, ref)
+		pending = append(pending, node)
+	}
+
+	errs := c.fanOut(ctx, len(pending), func(ctx context.Context, i int) error {
+		events, err := c.Events.List(ctx, &refs[i])
+		if err != nil {
+			return err
+		}
+
+		for _, evt := range events.Items {
+			if evt.Type == v12.EventTypeWarning {
+				pending[i].events = append(pending[i].events, evt.Message)
+			}
+		}
+		return nil
+	})
+
+	var res []Warning
+	for i, err := range errs {
+		if err == nil || k8sErrors.IsForbidden(err) {
+			continue
+		}
+
+		log.Warnf("Failed to get events for %s %s: %v", refs[i].Kind, refs[i].Name, err)
+		res = append(res, Warning{
+			Kind:    refs[i].GroupVersionKind().GroupKind().String(),
+			Message: "failed to get events of " + refs[i].Name + ": " + err.Error(),
+		})
+	}
+	return res
+}
+
+func isNestedXR(mr *ManagedUnstructured, nestedXRs []v12.ObjectReference) bool {
+	for _, ref := range nestedXRs {
+		if ref.Name == mr.GetName() && ref.Kind == mr.GetKind() && ref.Namespace == mr.GetNamespace() {
+			return true
+		}
+	}
+	return false
+}
+
+func objKey(obj *unstructured.Unstructured) string {
+	return obj.GetAPIVersion() + "/" + obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// findRootCauses returns unhealthy nodes that have no unhealthy descendants, the most probable ones first
+func findRootCauses(root *diagNode) []RootCause {
+	res := []RootCause{}
+	collectRootCauses(root, &res)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Depth != b.Depth {
+			return a.Depth > b.Depth
+		}
+		if rank(a.Condition) != rank(b.Condition) {
+			return rank(a.Condition) < rank(b.Condition)
+		}
+		return len(a.Events) > len(b.Events)
+	})
+	return res
+}
+
+// collectRootCauses returns true if the node or any of its descendants is unhealthy
+func collectRootCauses(node *diagNode, res *[]RootCause) bool {
+	childUnhealthy := false
+	for _, child := range node.children {
+		childUnhealthy = collectRootCauses(child, res) || childUnhealthy
+	}
+
+	cond := failedCondition(node.obj)
+	if cond == nil && len(node.events) == 0 {
+		return childUnhealthy
+	}
+
+	if !childUnhealthy {
+		gvk := node.obj.GroupVersionKind()
+		cause := RootCause{
+			ObjectRef: ObjectRef{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Namespace:  node.obj.GetNamespace(),
+				Name:       node.obj.GetName(),
+			},
+			Depth:  node.depth,
+			Events: node.events,
+		}
+		if cond != nil {
+			cause.Condition = string(cond.Type)
+			cause.Reason = string(cond.Reason)
+			cause.Message = cond.Message
+		}
+		*res = append(*res, cause)
+	}
+	return true
+}
+
+// failedCondition picks the most telling of conditions with False status
+func failedCondition(obj *unstructured.Unstructured) *xpv1.Condition {
+	for _, typ := range []xpv1.ConditionType{typeFound, xpv1.TypeSynced, xpv1.TypeReady} {
+		summary := conditionSummary(obj, typ)
+		if summary != nil && summary.Status == string(v12.ConditionFalse) {
+			return &xpv1.Condition{
+				Type:    typ,
+				Status:  v12.ConditionFalse,
+				Reason:  xpv1.ConditionReason(summary.Reason),
+				Message: summary.Message,
+			}
+		}
+	}
+	return nil
+}
+
+func rank(condition string) int {
+	switch xpv1.ConditionType(condition) {
+	case typeFound:
+		return 0
+	case xpv1.TypeSynced:
+		return 1
+	case xpv1.TypeReady:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func diagObj(kind, name string, conds ...map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("example.org/v1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	list := []interface{}{}
+	for _, cond := range conds {
+		list = append(list, cond)
+	}
+	obj.Object["status"] = map[string]interface{}{"conditions": list}
+	return obj
+}
+
+func cond(typ, status, reason string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "status": status, "reason": reason, "message": reason + " message"}
+}
+
+func TestFindRootCauses(t *testing.T) {
+	root := &diagNode{
+		obj:   diagObj("XApp", "app", cond("Ready", "False", "Creating")),
+		depth: 1,
+		children: []*diagNode{
+			{
+				obj:   diagObj("XNetwork", "net", cond("Ready", "False", "Creating")),
+				depth: 2,
+				children: []*diagNode{
+					{obj: diagObj("VPC", "vpc", cond("Ready", "True", "Available")), depth: 3},
+					{obj: diagObj("Subnet", "subnet", cond("Synced", "False", "ReconcileError"), cond("Ready", "False", "Creating")), depth: 3},
+					{obj: diagObj("RouteTable", "rt", cond("Ready", "False", "Creating")), depth: 3, events: []string{"cannot create"}},
+				},
+			},
+			{obj: diagObj("Bucket", "bucket", cond("Found", "False", "FailedToGet")), depth: 2},
+			{obj: diagObj("Queue", "queue", cond("Ready", "True", "Available")), depth: 2},
+		},
+	}
+
+	res := findRootCauses(root)
+	require.Len(t, res, 3)
+
+	assert.Equal(t, "subnet", res[0].Name)
+	assert.Equal(t, "Synced", res[0].Condition)
+	assert.Equal(t, "ReconcileError", res[0].Reason)
+	assert.Equal(t, 3, res[0].Depth)
+
+	assert.Equal(t, "rt", res[1].Name)
+	assert.Equal(t, []string{"cannot create"}, res[1].Events)
+
+	assert.Equal(t, "bucket", res[2].Name)
+	assert.Equal(t, "Found", res[2].Condition)
+}
+
+func TestFindRootCauses_Healthy(t *testing.T) {
+	root := &diagNode{
+		obj:      diagObj("XApp", "app", cond("Ready", "True", "Available")),
+		children: []*diagNode{{obj: diagObj("Bucket", "bucket", cond("Ready", "True", "Available"))}},
+	}
+	assert.Empty(t, findRootCauses(root))
+}
+
+func TestFindRootCauses_ParentOnly(t *testing.T) {
+	root := &diagNode{
+		obj:      diagObj("XApp", "app", cond("Synced", "False", "CompositionInvalid")),
+		children: []*diagNode{{obj: diagObj("Bucket", "bucket", cond("Ready", "True", "Available")), depth: 1}},
+	}
+
+	res := findRootCauses(root)
+	require.Len(t, res, 1)
+	assert.Equal(t, "app", res[0].Name)
+}
+
+// fakeEvents has a warning for each object, except the Broken ones that fail
+type fakeEvents struct {
+	lists atomic.Int32
+}
+
+func (f *fakeEvents) List(ctx context.Context, ref *v12.ObjectReference) (*v12.EventList, error) {
+	f.lists.Add(1)
+	if ref.Kind == "Broken" {
+		return nil, errors.New("connection reset")
+	}
+	return &v12.EventList{Items: []v12.Event{
+		{Type: v12.EventTypeNormal, Message: "all good"},
+		{Type: v12.EventTypeWarning, Message: "cannot create " + ref.Name},
+	}}, nil
+}
+
+func (f *fakeEvents) Create(ctx context.Context, ref *v12.ObjectReference, eventType string, reason string, message string) error {
+	return nil
+}
+
+func TestFillWarningEvents(t *testing.T) {
+	events := &fakeEvents{}
+	c := &Controller{Events: events, callTimeout: time.Minute}
+	root := &diagNode{
+		obj: diagObj("XApp", "app", cond("Ready", "False", "Creating")),
+		children: []*diagNode{
+			{obj: diagObj("VPC", "vpc", cond("Ready", "True", "Available"))},
+			{obj: diagObj("Subnet", "subnet", cond("Ready", "False", "Creating"))},
+			{obj: diagObj("Broken", "broken", cond("Ready", "False", "Creating"))},
+		},
+	}
+
+	warnings := c.fillWarningEvents(context.Background(), root.flatten(nil))
+	assert.Equal(t, int32(3), events.lists.Load(), "ready ones are skipped")
+	assert.Equal(t, []string{"cannot create app"}, root.events)
+	assert.Empty(t, root.children[0].events)
+	assert.Equal(t, []string{"cannot create subnet"}, root.children[1].events)
+	assert.Equal(t, []Warning{{Kind: "Broken.example.org", Message: "failed to get events of broken: connection reset"}}, warnings)
+}
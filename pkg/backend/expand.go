@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const maxExpandDepth = 10
+
+// parseDepth reads how many levels of nested XRs to expand, 0 means direct resources only
+func parseDepth(ec echo.Context) (int, error) {
+	param := ec.QueryParam("depth")
+	if param == "" {
+		return 0, nil
+	}
+
+	depth, err := strconv.Atoi(param)
+	if err != nil || depth < 0 || depth > maxExpandDepth {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "depth has to be a number from 0 to "+strconv.Itoa(maxExpandDepth))
+	}
+	return depth, nil
+}
+
+// getManaged loads all the referenced resources at once, keeping their order, and returns the errors by index.
+// The resources that failed to load are left empty.
+func (c *Controller) getManaged(ctx context.Context, refs []v12.ObjectReference) ([]*ManagedUnstructured, []error) {
+	res := make([]*ManagedUnstructured, len(refs))
+	for i := range res {
+		res[i] = NewManagedUnstructured()
+	}
+
+	errs := c.fanOut(ctx, len(refs), func(ctx context.Context, i int) error {
+		return c.getDynamicResource(ctx, &refs[i], res[i])
+	})
+	return res, errs
+}
+
+// fetchWarnings skips the resources that are gone or hidden by RBAC, as these are expected
+func fetchWarnings(refs []v12.ObjectReference, errs []error) []Warning {
+	var res []Warning
+	for i, err := range errs {
+		if err == nil || k8sErrors.IsNotFound(err) || k8sErrors.IsForbidden(err) {
+			continue
+		}
+
+		log.Warnf("Failed to get %s %s: %v", refs[i].Kind, refs[i].Name, err)
+		res = append(res, Warning{
+			Kind:    refs[i].GroupVersionKind().GroupKind().String(),
+			Message: "failed to get " + refs[i].Name + ": " + err.Error(),
+		})
+	}
+	return res
+}
+
+// expandNestedXRs loads the resources of nested XRs in place, so they become part of the response.
+// It goes level by level to load each level at once, and expands each XR once, that stops the cycles of XRs referencing each other.
+func (c *Controller) expandNestedXRs(ec echo.Context, xr *uxres.Unstructured, depth int) {
+	visited := map[string]bool{objKey(&xr.Unstructured): true}
+	level := []*uxres.Unstructured{xr}
+	for ; depth > 0 && len(level) > 0; depth-- {
+		next := []*uxres.Unstructured{}
+		for _, parent := range level {
+			MRs, _ := parent.Object["managedResources"].([]*ManagedUnstructured)
+			nestedXRs, _ := parent.Object["managedResourcesXRs"].([]v12.ObjectReference)
+			for _, mr := range MRs {
+				if !isNestedXR(mr, nestedXRs) {
+					continue
+				}
+
+				key := objKey(&mr.Unstructured.Unstructured)
+				if visited[key] {
+					log.Debugf("Skipping already expanded %s %s", mr.GetKind(), mr.GetName())
+					continue
+				}
+				visited[key] = true
+
+				nested := uxres.New()
+				nested.Object = mr.Unstructured.Unstructured.Object
+				next = append(next, nested)
+			}
+		}
+
+		if err := c.fillManagedResources(ec, next...); err != nil {
+			log.Warnf("Failed to get resources of nested XRs: %v", err)
+			return
+		}
+		level = next
+	}
+}
@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v12 "k8s.io/api/core/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeCRDs serves objects by reference, counting the calls
+type fakeCRDs struct {
+	objects map[string]*unstructured.Unstructured
+	gets    atomic.Int32
+}
+
+func (f *fakeCRDs) List(_ context.Context, _ schema.GroupVersionKind) (*unstructured.UnstructuredList, error) {
+	return &unstructured.UnstructuredList{}, nil
+}
+
+func (f *fakeCRDs) Get(_ context.Context, dst resource.Object, ref *v12.ObjectReference) error {
+	f.gets.Add(1)
+	obj, found := f.objects[ref.APIVersion+"/"+ref.Kind+"/"+ref.Namespace+"/"+ref.Name]
+	if !found {
+		return k8sErrors.NewNotFound(schema.GroupResource{Resource: ref.Kind}, ref.Name)
+	}
+	dst.(runtime.Unstructured).SetUnstructuredContent(obj.DeepCopy().Object)
+	return nil
+}
+
+func (f *fakeCRDs) Patch(_ context.Context, _ resource.Object, _ *v12.ObjectReference, _ []byte) error {
+	return nil
+}
+
+// xrWithRefs makes XNetwork, or Subnet MR for the names starting with "m"
+func xrWithRefs(name string, refs ...string) *unstructured.Unstructured {
+	kindOf := func(name string) string {
+		if name[0] == 'm' {
+			return "Subnet"
+		}
+		return "XNetwork"
+	}
+
+	items := []interface{}{}
+	for _, ref := range refs {
+		items = append(items, refTo("example.org/v1", kindOf(ref), ref))
+	}
+	return graphObj("example.org/v1", kindOf(name), "", name, map[string]interface{}{"resourceRefs": items})
+}
+
+func TestExpandNestedXRs(t *testing.T) {
+	crds := &fakeCRDs{objects: map[string]*unstructured.Unstructured{}}
+	for _, obj := range []*unstructured.Unstructured{
+		xrWithRefs("a", "b", "m1"),
+		xrWithRefs("b", "c", "a"), // a cycle back to the root
+		xrWithRefs("c", "m2"),
+		xrWithRefs("m1"),
+		xrWithRefs("m2"),
+	} {
+		crds.objects[objKey(obj)] = obj
+	}
+
+	xrds := &cpext.CompositeResourceDefinitionList{Items: []cpext.CompositeResourceDefinition{{
+		Spec: cpext.CompositeResourceDefinitionSpec{
+			Group:    "example.org",
+			Names:    apiextv1.CustomResourceDefinitionNames{Kind: "XNetwork"},
+			Versions: []cpext.CompositeResourceDefinitionVersion{{Name: "v1"}},
+		},
+	}}}
+
+	load := func(depth int) *uxres.Unstructured {
+		ec := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		ec.Set("XRDs", xrds)
+		c := &Controller{CRDs: crds, StatusInfo: &StatusInfo{}}
+
+		xr := uxres.New()
+		xr.Object = crds.objects["example.org/v1/XNetwork//a"].DeepCopy().Object
+		require.NoError(t, c.fillManagedResources(ec, xr))
+		c.expandNestedXRs(ec, xr, depth)
+		return xr
+	}
+
+	nested := func(xr map[string]interface{}, idx int) map[string]interface{} {
+		return xr["managedResources"].([]*ManagedUnstructured)[idx].Object
+	}
+
+	xr := load(0)
+	assert.NotContains(t, nested(xr.Object, 0), "managedResources")
+
+	xr = load(1)
+	b := nested(xr.Object, 0)
+	require.Contains(t, b, "managedResources")
+	assert.Len(t, b["managedResourcesXRs"], 2)
+	assert.NotContains(t, nested(b, 0), "managedResources", "beyond the depth")
+
+	crds.gets.Store(0)
+	xr = load(maxExpandDepth)
+	third := nested(nested(xr.Object, 0), 0)
+	require.Contains(t, third, "managedResources")
+	assert.Equal(t, "m2", third["managedResources"].([]*ManagedUnstructured)[0].GetName())
+	assert.NotContains(t, nested(nested(xr.Object, 0), 1), "managedResources", "the root is not expanded again")
+	assert.Equal(t, int32(5), crds.gets.Load(), "each of b, m1, c, a and m2 is loaded once")
+}
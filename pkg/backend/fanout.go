@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const fetchConcurrency = 8 // API requests at once while fanning out
+
+// Warning tells about a kind that failed to load, the response still has everything else
+type Warning struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// withCallTimeout limits a single API call, so one slow kind does not hold the whole response
+func (c *Controller) withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, c.callTimeout)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// fanOut makes n calls on a bounded pool of workers, each under own timeout, and returns their errors by index.
+// Once ctx is cancelled, the calls not started yet are skipped with its error.
+func (c *Controller) fanOut(ctx context.Context, n int, call func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	tasks := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < fetchConcurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+
+				callCtx, cancel := c.withCallTimeout(ctx)
+				errs[i] = call(callCtx, i)
+				cancel()
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		tasks <- i
+	}
+	close(tasks)
+	wg.Wait()
+	return errs
+}
+
+// addWarnings appends to the warnings of the object in response
+func addWarnings(obj *unstructured.Unstructured, warnings []Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	existing, _ := obj.Object["warnings"].([]Warning)
+	obj.Object["warnings"] = append(existing, warnings...)
+}
+
+func unsyncedWarnings(kinds []schema.GroupKind) []Warning {
+	var res []Warning
+	for _, gk := range kinds {
+		res = append(res, Warning{Kind: gk.String(), Message: "did not sync in time, the list is partial"})
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFanOut(t *testing.T) {
+	c := &Controller{callTimeout: time.Minute}
+
+	running, maxRunning := atomic.Int32{}, atomic.Int32{}
+	errs := c.fanOut(context.Background(), 50, func(ctx context.Context, i int) error {
+		cur := running.Add(1)
+		defer running.Add(-1)
+		for prev := maxRunning.Load(); cur > prev && !maxRunning.CompareAndSwap(prev, cur); prev = maxRunning.Load() {
+		}
+
+		if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+			return errors.New("no timeout")
+		}
+		time.Sleep(time.Millisecond)
+		if i%10 == 0 {
+			return errors.New(strconv.Itoa(i))
+		}
+		return nil
+	})
+
+	require.Len(t, errs, 50)
+	for i, err := range errs {
+		if i%10 == 0 {
+			assert.EqualError(t, err, strconv.Itoa(i))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.LessOrEqual(t, maxRunning.Load(), int32(fetchConcurrency))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	errs = c.fanOut(ctx, 3, func(ctx context.Context, i int) error {
+		calls++
+		return nil
+	})
+	assert.Equal(t, 0, calls, "the request is gone")
+	assert.Equal(t, []error{context.Canceled, context.Canceled, context.Canceled}, errs)
+}
+
+// failingCRDs fails to get the objects of Broken kind
+type failingCRDs struct {
+	fakeCRDs
+}
+
+func (f *failingCRDs) Get(ctx context.Context, dst resource.Object, ref *v12.ObjectReference) error {
+	if ref.Kind == "Broken" {
+		return errors.New("connection reset")
+	}
+	return f.fakeCRDs.Get(ctx, dst, ref)
+}
+
+func TestFillManagedResources_Warnings(t *testing.T) {
+	crds := &failingCRDs{fakeCRDs{objects: map[string]*unstructured.Unstructured{}}}
+	m1 := xrWithRefs("m1")
+	crds.objects[objKey(m1)] = m1
+
+	xr := uxres.New()
+	xr.Object = graphObj("example.org/v1", "XNetwork", "", "a", map[string]interface{}{"resourceRefs": []interface{}{
+		refTo("example.org/v1", "Subnet", "m1"),
+		refTo("example.org/v1", "Subnet", "gone"),
+		refTo("example.org/v1", "Broken", "m2"),
+	}}).Object
+
+	ec := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	ec.Set("XRDs", &cpext.CompositeResourceDefinitionList{})
+	c := &Controller{CRDs: crds, StatusInfo: &StatusInfo{}}
+	require.NoError(t, c.fillManagedResources(ec, xr))
+
+	MRs := xr.Object["managedResources"].([]*ManagedUnstructured)
+	require.Len(t, MRs, 3, "the failed ones are still there")
+	assert.Equal(t, "m1", MRs[0].GetName())
+	assert.Equal(t, []Warning{{Kind: "Broken.example.org", Message: "failed to get m2: connection reset"}}, xr.Object["warnings"],
+		"missing resources are not a warning")
+
+	other := uxres.New()
+	other.Object = graphObj("example.org/v1", "XNetwork", "", "b", map[string]interface{}{"resourceRefs": []interface{}{
+		refTo("example.org/v1", "Subnet", "m1"),
+	}}).Object
+	require.NoError(t, c.fillManagedResources(ec, xr, other))
+	assert.Len(t, xr.Object["managedResources"], 3)
+	require.Len(t, other.Object["managedResources"], 1, "the resources loaded at once are split back by XR")
+	assert.Equal(t, "m1", other.Object["managedResources"].([]*ManagedUnstructured)[0].GetName())
+	assert.NotContains(t, other.Object, "warnings")
+}
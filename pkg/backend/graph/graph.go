@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type NodeType string
+
+const (
+	TypeClaim          NodeType = "claim"
+	TypeComposite      NodeType = "composite"
+	TypeManaged        NodeType = "managed"
+	TypeComposition    NodeType = "composition"
+	TypeXRD            NodeType = "xrd"
+	TypeProviderConfig NodeType = "providerconfig"
+	TypeProvider       NodeType = "provider"
+	TypeSecret         NodeType = "secret"
+)
+
+type Status string
+
+const (
+	StatusHealthy   Status = "Healthy"
+	StatusUnhealthy Status = "Unhealthy"
+	StatusMissing   Status = "Missing"
+	StatusUnknown   Status = "Unknown"
+)
+
+// relations between nodes, the edge goes from the first object to the second
+const (
+	RelComposite      = "composite"         // claim to its XR
+	RelComposes       = "composes"          // XR to composed resources
+	RelComposition    = "composition"       // XR to the composition selected for it
+	RelDefinedBy      = "definedBy"         // claim or XR to its XRD
+	RelProviderConfig = "providerConfig"    // MR to ProviderConfig
+	RelProvider       = "provider"          // MR or ProviderConfig to the provider
+	RelSecret         = "connectionSecret"  // to the Secret with connection details
+	RelCredentials    = "credentialsSecret" // ProviderConfig to the Secret with credentials
+)
+
+type Node struct {
+	ID         string   `json:"id"`
+	Type       NodeType `json:"type"`
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name"`
+	Status     Status   `json:"status"`
+	Reason     string   `json:"reason,omitempty"`
+	Message    string   `json:"message,omitempty"`
+}
+
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// Graph holds objects as nodes, each added once under its ID, and relations between them as edges
+type Graph struct {
+	Root  string  `json:"root"`
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+
+	nodes map[string]*Node
+	edges map[Edge]bool
+}
+
+func New() *Graph {
+	return &Graph{
+		Nodes: []*Node{},
+		Edges: []Edge{},
+		nodes: map[string]*Node{},
+		edges: map[Edge]bool{},
+	}
+}
+
+// NodeID does not include the version, as references to the same object might use different versions
+func NodeID(apiVersion string, kind string, namespace string, name string) string {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+	parts := []string{gv.Group, kind, namespace, name}
+	if gv.Group == "" {
+		parts[0] = "core"
+	}
+	if namespace == "" {
+		parts = append(parts[:2], name)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Add returns the node that is already in the graph under the same ID, telling whether the given one was added
+func (g *Graph) Add(node *Node) (*Node, bool) {
+	if node.ID == "" {
+		node.ID = NodeID(node.APIVersion, node.Kind, node.Namespace, node.Name)
+	}
+
+	if existing, found := g.nodes[node.ID]; found {
+		return existing, false
+	}
+
+	g.nodes[node.ID] = node
+	g.Nodes = append(g.Nodes, node)
+	return node, true
+}
+
+func (g *Graph) Get(id string) *Node {
+	return g.nodes[id]
+}
+
+func (g *Graph) Connect(from *Node, to *Node, relation string) {
+	edge := Edge{From: from.ID, To: to.ID, Relation: relation}
+	if !g.edges[edge] {
+		g.edges[edge] = true
+		g.Edges = append(g.Edges, edge)
+	}
+}
+
+var dotColors = map[Status]string{
+	StatusHealthy:   "#c8e6c9",
+	StatusUnhealthy: "#ffcdd2",
+	StatusMissing:   "#e0e0e0",
+	StatusUnknown:   "#fff9c4",
+}
+
+// DOT renders the graph for Graphviz, colored by status
+func (g *Graph) DOT() string {
+	ids := g.shortIDs()
+
+	sb := strings.Builder{}
+	sb.WriteString("digraph komoplane {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	for _, node := range g.Nodes {
+		_, _ = fmt.Fprintf(&sb, "  %s [label=%s, fillcolor=%q];\n", ids[node.ID], dotQuote(label(node, `\n`)), dotColors[node.Status])
+	}
+	for _, edge := range g.Edges {
+		_, _ = fmt.Fprintf(&sb, "  %s -> %s [label=%q];\n", ids[edge.From], ids[edge.To], edge.Relation)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as flowchart, which can be pasted into Markdown documents
+func (g *Graph) Mermaid() string {
+	ids := g.shortIDs()
+
+	sb := strings.Builder{}
+	sb.WriteString("graph LR\n")
+	byStatus := map[Status][]string{}
+	for _, node := range g.Nodes {
+		text := strings.ReplaceAll(label(node, "<br/>"), `"`, "#quot;")
+		_, _ = fmt.Fprintf(&sb, "  %s[\"%s\"]\n", ids[node.ID], text)
+		byStatus[node.Status] = append(byStatus[node.Status], ids[node.ID])
+	}
+	for _, edge := range g.Edges {
+		_, _ = fmt.Fprintf(&sb, "  %s -->|%s| %s\n", ids[edge.From], edge.Relation, ids[edge.To])
+	}
+	for _, status := range []Status{StatusHealthy, StatusUnhealthy, StatusMissing, StatusUnknown} {
+		if len(byStatus[status]) == 0 {
+			continue
+		}
+		class := strings.ToLower(string(status))
+		_, _ = fmt.Fprintf(&sb, "  classDef %s fill:%s\n", class, dotColors[status])
+		_, _ = fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(byStatus[status], ","), class)
+	}
+	return sb.String()
+}
+
+// shortIDs gives nodes identifiers that are safe to use in DOT and Mermaid
+func (g *Graph) shortIDs() map[string]string {
+	res := map[string]string{}
+	for i, node := range g.Nodes {
+		res[node.ID] = fmt.Sprintf("n%d", i)
+	}
+	return res
+}
+
+func label(node *Node, newline string) string {
+	name := node.Name
+	if node.Namespace != "" {
+		name = node.Namespace + "/" + name
+	}
+	return node.Kind + newline + name
+}
+
+// dotQuote keeps the newline escape sequence in labels as is
+func dotQuote(str string) string {
+	return `"` + strings.ReplaceAll(str, `"`, `\"`) + `"`
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *Graph {
+	g := New()
+	claim, _ := g.Add(&Node{Type: TypeClaim, APIVersion: "example.org/v1", Kind: "Bucket", Namespace: "team", Name: "data", Status: StatusUnhealthy})
+	xr, _ := g.Add(&Node{Type: TypeComposite, APIVersion: "example.org/v1", Kind: "XBucket", Name: "data-x1", Status: StatusHealthy})
+	mr, _ := g.Add(&Node{Type: TypeManaged, APIVersion: "s3.aws.upbound.io/v1beta1", Kind: "Bucket", Name: `say "hi"`, Status: StatusMissing})
+	g.Connect(claim, xr, RelComposite)
+	g.Connect(xr, mr, RelComposes)
+	g.Connect(xr, mr, RelComposes)
+	return g
+}
+
+func TestNodeID(t *testing.T) {
+	assert.Equal(t, "example.org/Bucket/team/data", NodeID("example.org/v1", "Bucket", "team", "data"))
+	assert.Equal(t, "example.org/XBucket/data", NodeID("example.org/v1alpha1", "XBucket", "", "data"))
+	assert.Equal(t, "core/Secret/team/creds", NodeID("v1", "Secret", "team", "creds"))
+}
+
+func TestGraph_Add(t *testing.T) {
+	g := testGraph()
+	require.Len(t, g.Nodes, 3)
+	assert.Len(t, g.Edges, 2, "duplicate edges are skipped")
+
+	existing, added := g.Add(&Node{APIVersion: "example.org/v2", Kind: "XBucket", Name: "data-x1"})
+	assert.False(t, added)
+	assert.Equal(t, StatusHealthy, existing.Status)
+	assert.Same(t, existing, g.Get("example.org/XBucket/data-x1"))
+	assert.Len(t, g.Nodes, 3)
+}
+
+func TestGraph_DOT(t *testing.T) {
+	assert.Equal(t, `digraph komoplane {
+  rankdir=LR;
+  node [shape=box, style="rounded,filled", fontname="Helvetica"];
+  n0 [label="Bucket\nteam/data", fillcolor="#ffcdd2"];
+  n1 [label="XBucket\ndata-x1", fillcolor="#c8e6c9"];
+  n2 [label="Bucket\nsay \"hi\"", fillcolor="#e0e0e0"];
+  n0 -> n1 [label="composite"];
+  n1 -> n2 [label="composes"];
+}
+`, testGraph().DOT())
+}
+
+func TestGraph_Mermaid(t *testing.T) {
+	assert.Equal(t, `graph LR
+  n0["Bucket<br/>team/data"]
+  n1["XBucket<br/>data-x1"]
+  n2["Bucket<br/>say #quot;hi#quot;"]
+  n0 -->|composite| n1
+  n1 -->|composes| n2
+  classDef healthy fill:#c8e6c9
+  class n1 healthy
+  classDef unhealthy fill:#ffcdd2
+  class n0 unhealthy
+  classDef missing fill:#e0e0e0
+  class n2 missing
+`, testGraph().Mermaid())
+}
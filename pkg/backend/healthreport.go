@@ -0,0 +1,374 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/crossplane"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	defaultStaleAfter = time.Hour
+	maxGroupObjects   = 20 // objects listed per not-ready group, the count still includes all of them
+)
+
+// HealthReport aggregates what is wrong with Crossplane in the cluster, empty sections mean no problems
+type HealthReport struct {
+	Healthy       bool            `json:"healthy"`
+	StaleAfter    string          `json:"staleAfter"`
+	Packages      []HealthIssue   `json:"packages"`      // providers and functions not installed or unhealthy
+	XRDs          []HealthIssue   `json:"xrds"`          // not established or not offered
+	Compositions  []HealthIssue   `json:"compositions"`  // referencing kinds or functions that are missing or unhealthy
+	NotReady      []NotReadyGroup `json:"notReady"`      // claims, XRs and MRs grouped by class and reason
+	StuckDeleting []HealthIssue   `json:"stuckDeleting"` // MRs kept by finalizers for longer than the threshold
+	Stale         []HealthIssue   `json:"stale"`         // not ready or not synced for longer than the threshold
+	Forbidden     []string        `json:"forbidden,omitempty"`
+
+	staleAfter time.Duration
+}
+
+type HealthIssue struct {
+	ObjectRef
+	Problem string       `json:"problem"`
+	Since   *metav1.Time `json:"since,omitempty"`
+}
+
+type NotReadyGroup struct {
+	Class   string      `json:"class"`
+	Reason  string      `json:"reason"`
+	Count   int         `json:"count"`
+	Objects []ObjectRef `json:"objects"`
+}
+
+func (c *Controller) GetHealthReport(ec echo.Context) error {
+	staleAfter := defaultStaleAfter
+	if param := ec.QueryParam("staleAfter"); param != "" {
+		var err error
+		staleAfter, err = time.ParseDuration(param)
+		if err != nil || staleAfter <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid staleAfter: "+param)
+		}
+	}
+
+	ctx := c.reqCtx(ec)
+	report := newHealthReport(staleAfter)
+
+	packages, err := c.healthPackages(ctx, report)
+	if err != nil {
+		return err
+	}
+	report.Packages = packageIssues(packages)
+
+	xrds, err := c.cachedListXRDs(ec)
+	if err != nil {
+		return err
+	}
+	report.XRDs, err = xrdIssues(xrds)
+	if err != nil {
+		return err
+	}
+
+	// unstructured, as the typed client drops pipeline mode that is newer than our Crossplane library
+	compositions, err := c.CRDs.List(ctx, cpext.CompositionGroupVersionKind.GroupVersion().WithKind("compositions"))
+	if forbidden(err, "compositions") {
+		compositions, err = &unstructured.UnstructuredList{}, nil
+		report.Forbidden = append(report.Forbidden, cpext.CompositionKind)
+	}
+	if err != nil {
+		return err
+	}
+
+	functions := []unstructured.Unstructured{}
+	for _, pkg := range packages {
+		if pkg.GetKind() == crossplane.FunctionKind {
+			functions = append(functions, pkg)
+		}
+	}
+	functionsDenied := slices.Contains(report.Forbidden, crossplane.FunctionKind)
+	report.Compositions = compositionIssues(compositions.Items, xrds, knownKinds(c.Tracker.CRDs(ctx)), functions, functionsDenied)
+
+	now := time.Now()
+	for _, class := range []tracker.Class{tracker.ClassClaim, tracker.ClassComposite, tracker.ClassManaged} {
+		items, forbiddenKinds := c.listVisible(ec, class)
+		report.Forbidden = append(report.Forbidden, forbiddenKinds...)
+		report.addResources(class, items, now)
+	}
+
+	report.finish()
+	return ec.JSONPretty(http.StatusOK, report, "  ")
+}
+
+// healthPackages lists providers and functions, noting the ones hidden from the viewer
+func (c *Controller) healthPackages(ctx context.Context, report *HealthReport) ([]unstructured.Unstructured, error) {
+	res := []unstructured.Unstructured{}
+
+	providers, err := c.APIv1.Providers().List(ctx)
+	if forbidden(err, "providers") {
+		providers, err = &cpv1.ProviderList{}, nil
+		report.Forbidden = append(report.Forbidden, cpv1.ProviderKind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range providers.Items {
+		obj, err := toUnstructured(&providers.Items[i], cpv1.ProviderGroupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *obj)
+	}
+
+	functions, err := c.APIv1.Functions().List(ctx)
+	if forbidden(err, "functions") {
+		report.Forbidden = append(report.Forbidden, crossplane.FunctionKind)
+		functions, err = &unstructured.UnstructuredList{}, nil
+	}
+	if k8sErrors.IsNotFound(err) { // older Crossplane has no functions
+		functions, err = &unstructured.UnstructuredList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append(res, functions.Items...), nil
+}
+
+func newHealthReport(staleAfter time.Duration) *HealthReport {
+	return &HealthReport{
+		StaleAfter:    staleAfter.String(),
+		staleAfter:    staleAfter,
+		Packages:      []HealthIssue{},
+		XRDs:          []HealthIssue{},
+		Compositions:  []HealthIssue{},
+		NotReady:      []NotReadyGroup{},
+		StuckDeleting: []HealthIssue{},
+		Stale:         []HealthIssue{},
+	}
+}
+
+// addResources groups not ready objects, and finds the ones that are stale or stuck in deletion
+func (r *HealthReport) addResources(class tracker.Class, items []unstructured.Unstructured, now time.Time) {
+	threshold := now.Add(-r.staleAfter)
+	groups := map[string]*NotReadyGroup{}
+	for i := range items {
+		obj := &items[i]
+		ref := ObjectRef{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+		ready := conditionSummary(obj, xpv1.TypeReady)
+		if ready == nil || ready.Status != string(metav1.ConditionTrue) {
+			reason := "Unknown"
+			if ready != nil && ready.Reason != "" {
+				reason = ready.Reason
+			}
+
+			group, found := groups[reason]
+			if !found {
+				group = &NotReadyGroup{Class: string(class), Reason: reason, Objects: []ObjectRef{}}
+				groups[reason] = group
+			}
+			group.Count++
+			if len(group.Objects) < maxGroupObjects {
+				group.Objects = append(group.Objects, ref)
+			}
+		}
+
+		for _, typ := range []xpv1.ConditionType{xpv1.TypeReady, xpv1.TypeSynced} {
+			cond := conditionSummary(obj, typ)
+			if cond == nil || cond.Status == string(metav1.ConditionTrue) || cond.LastTransitionTime.IsZero() {
+				continue
+			}
+
+			if cond.LastTransitionTime.Time.Before(threshold) {
+				since := cond.LastTransitionTime
+				r.Stale = append(r.Stale, HealthIssue{ObjectRef: ref, Problem: conditionProblem(cond, string(typ)), Since: &since})
+				break
+			}
+		}
+
+		deleted := obj.GetDeletionTimestamp()
+		if class == tracker.ClassManaged && deleted != nil && len(obj.GetFinalizers()) > 0 && deleted.Time.Before(threshold) {
+			r.StuckDeleting = append(r.StuckDeleting, HealthIssue{
+				ObjectRef: ref,
+				Problem:   "kept by finalizers: " + strings.Join(obj.GetFinalizers(), ", "),
+				Since:     deleted,
+			})
+		}
+	}
+
+	reasons := make([]string, 0, len(groups))
+	for reason := range groups {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if groups[reasons[i]].Count != groups[reasons[j]].Count {
+			return groups[reasons[i]].Count > groups[reasons[j]].Count
+		}
+		return reasons[i] < reasons[j]
+	})
+	for _, reason := range reasons {
+		r.NotReady = append(r.NotReady, *groups[reason])
+	}
+}
+
+func (r *HealthReport) finish() {
+	sort.Strings(r.Forbidden)
+	r.Healthy = len(r.Packages) == 0 && len(r.XRDs) == 0 && len(r.Compositions) == 0 &&
+		len(r.NotReady) == 0 && len(r.StuckDeleting) == 0 && len(r.Stale) == 0
+}
+
+// conditionProblem describes the condition that is not True, like "not Installed: UnpackingPackage, message"
+func conditionProblem(cond *ConditionSummary, typ string) string {
+	if cond == nil {
+		return "no " + typ + " condition"
+	}
+
+	res := "not " + typ
+	if cond.Reason != "" {
+		res += ": " + cond.Reason
+	}
+	if cond.Message != "" {
+		res += ", " + cond.Message
+	}
+	return res
+}
+
+func packageIssues(packages []unstructured.Unstructured) []HealthIssue {
+	res := []HealthIssue{}
+	for i := range packages {
+		pkg := &packages[i]
+		for _, typ := range []xpv1.ConditionType{cpv1.TypeInstalled, cpv1.TypeHealthy} {
+			if issue := conditionIssue(pkg, typ); issue != nil {
+				res = append(res, *issue)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func xrdIssues(xrds *cpext.CompositeResourceDefinitionList) ([]HealthIssue, error) {
+	res := []HealthIssue{}
+	for i := range xrds.Items {
+		xrd, err := toUnstructured(&xrds.Items[i], cpext.CompositeResourceDefinitionGroupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+
+		issue := conditionIssue(xrd, cpext.TypeEstablished)
+		if issue == nil && xrds.Items[i].Spec.ClaimNames != nil {
+			issue = conditionIssue(xrd, cpext.TypeOffered)
+		}
+		if issue != nil {
+			res = append(res, *issue)
+		}
+	}
+	return res, nil
+}
+
+// conditionIssue returns nil if the condition is True
+func conditionIssue(obj *unstructured.Unstructured, typ xpv1.ConditionType) *HealthIssue {
+	cond := conditionSummary(obj, typ)
+	if cond != nil && cond.Status == string(metav1.ConditionTrue) {
+		return nil
+	}
+
+	res := &HealthIssue{
+		ObjectRef: ObjectRef{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()},
+		Problem:   conditionProblem(cond, string(typ)),
+	}
+	if cond != nil && !cond.LastTransitionTime.IsZero() {
+		res.Since = &cond.LastTransitionTime
+	}
+	return res
+}
+
+func compositionIssues(compositions []unstructured.Unstructured, xrds *cpext.CompositeResourceDefinitionList, known map[schema.GroupKind]bool,
+	functions []unstructured.Unstructured, functionsDenied bool) []HealthIssue {
+	served := map[schema.GroupKind][]string{}
+	for _, xrd := range xrds.Items {
+		gk := schema.GroupKind{Group: xrd.Spec.Group, Kind: xrd.Spec.Names.Kind}
+		for _, ver := range xrd.Spec.Versions {
+			if ver.Served {
+				served[gk] = append(served[gk], ver.Name)
+			}
+		}
+	}
+
+	res := []HealthIssue{}
+	for i := range compositions {
+		comp := &compositions[i]
+		problems := []string{}
+
+		apiVersion, _, _ := unstructured.NestedString(comp.Object, "spec", "compositeTypeRef", "apiVersion")
+		kind, _, _ := unstructured.NestedString(comp.Object, "spec", "compositeTypeRef", "kind")
+		gv, _ := schema.ParseGroupVersion(apiVersion)
+		versions, found := served[gv.WithKind(kind).GroupKind()]
+		if !found {
+			problems = append(problems, fmt.Sprintf("composite type %s %s is not defined by any XRD", apiVersion, kind))
+		} else if !slices.Contains(versions, gv.Version) {
+			problems = append(problems, fmt.Sprintf("composite type %s %s is not served", apiVersion, kind))
+		}
+
+		templates, _, _ := unstructured.NestedSlice(comp.Object, "spec", "resources")
+		for j, raw := range templates {
+			tmpl, _ := raw.(map[string]interface{})
+			base, _, _ := unstructured.NestedMap(tmpl, "base")
+			obj := unstructured.Unstructured{Object: base}
+			if obj.GetKind() == "" {
+				continue
+			}
+
+			gk := obj.GroupVersionKind().GroupKind()
+			if !strings.Contains(gk.Group, ".") { // built-in kinds are not defined by CRDs
+				continue
+			}
+			if !known[gk] {
+				name := fmt.Sprintf("resource #%d", j)
+				if tmplName, _, _ := unstructured.NestedString(tmpl, "name"); tmplName != "" {
+					name = "resource " + tmplName
+				}
+				problems = append(problems, fmt.Sprintf("%s: kind %s %s is not installed", name, obj.GetAPIVersion(), obj.GetKind()))
+			}
+		}
+
+		pipeline := buildPipeline(comp, functions, functionsDenied) // no steps in Resources mode
+		for _, fn := range pipeline.Missing {
+			problems = append(problems, "function "+fn+" is not installed")
+		}
+		for _, fn := range pipeline.Unhealthy {
+			problems = append(problems, "function "+fn+" is not healthy")
+		}
+
+		if len(problems) > 0 {
+			res = append(res, HealthIssue{
+				ObjectRef: ObjectRef{APIVersion: cpext.CompositionGroupVersionKind.GroupVersion().String(), Kind: cpext.CompositionKind, Name: comp.GetName()},
+				Problem:   strings.Join(problems, "; "),
+			})
+		}
+	}
+	return res
+}
+
+func knownKinds(crds []*apiextv1.CustomResourceDefinition) map[schema.GroupKind]bool {
+	res := map[schema.GroupKind]bool{}
+	for _, crd := range crds {
+		res[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = true
+	}
+	return res
+}
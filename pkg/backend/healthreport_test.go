@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestHealthReport_AddResources(t *testing.T) {
+	now := time.Now()
+	longAgo := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+
+	staleCond := cond("Synced", "False", "ReconcileError")
+	staleCond["lastTransitionTime"] = longAgo
+
+	deleting := diagObj("Bucket", "deleting", cond("Ready", "True", "Available"))
+	deleting.SetDeletionTimestamp(&metav1.Time{Time: now.Add(-2 * time.Hour)})
+	deleting.SetFinalizers([]string{"finalizer.managedresource.crossplane.io"})
+
+	recent := diagObj("Bucket", "recent", cond("Ready", "True", "Available"))
+	recent.SetDeletionTimestamp(&metav1.Time{Time: now.Add(-time.Minute)})
+	recent.SetFinalizers([]string{"finalizer.managedresource.crossplane.io"})
+
+	items := []unstructured.Unstructured{
+		*diagObj("Bucket", "ok", cond("Ready", "True", "Available")),
+		*diagObj("Bucket", "creating1", cond("Ready", "False", "Creating")),
+		*diagObj("Bucket", "creating2", cond("Ready", "False", "Creating")),
+		*diagObj("Bucket", "stale", cond("Ready", "False", "Unavailable"), staleCond),
+		*diagObj("Bucket", "new"),
+		*deleting,
+		*recent,
+	}
+
+	report := newHealthReport(time.Hour)
+	report.addResources(tracker.ClassManaged, items, now)
+	report.finish()
+
+	assert.False(t, report.Healthy)
+	require.Len(t, report.NotReady, 3)
+	assert.Equal(t, "Creating", report.NotReady[0].Reason, "the largest group first")
+	assert.Equal(t, 2, report.NotReady[0].Count)
+	assert.Equal(t, "managed", report.NotReady[0].Class)
+	assert.Equal(t, "Unavailable", report.NotReady[1].Reason)
+	assert.Equal(t, "Unknown", report.NotReady[2].Reason, "no Ready condition yet")
+
+	require.Len(t, report.Stale, 1)
+	assert.Equal(t, "stale", report.Stale[0].Name)
+	assert.Equal(t, "not Synced: ReconcileError, ReconcileError message", report.Stale[0].Problem)
+
+	require.Len(t, report.StuckDeleting, 1)
+	assert.Equal(t, "deleting", report.StuckDeleting[0].Name)
+	assert.Equal(t, "kept by finalizers: finalizer.managedresource.crossplane.io", report.StuckDeleting[0].Problem)
+
+	report = newHealthReport(time.Hour)
+	report.addResources(tracker.ClassManaged, items[:1], now)
+	report.finish()
+	assert.True(t, report.Healthy)
+}
+
+func TestPackageIssues(t *testing.T) {
+	issues := packageIssues([]unstructured.Unstructured{
+		*diagObj("Provider", "ok", cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "True", "HealthyPackageRevision")),
+		*diagObj("Provider", "unhealthy", cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "False", "UnhealthyPackageRevision")),
+		*diagObj("Function", "new"),
+	})
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, "unhealthy", issues[0].Name)
+	assert.Equal(t, "not Healthy: UnhealthyPackageRevision, UnhealthyPackageRevision message", issues[0].Problem)
+	assert.Equal(t, "no Installed condition", issues[1].Problem)
+}
+
+func TestCompositionIssues(t *testing.T) {
+	xrds := &cpext.CompositeResourceDefinitionList{Items: []cpext.CompositeResourceDefinition{{
+		Spec: cpext.CompositeResourceDefinitionSpec{
+			Group:    "example.org",
+			Names:    apiextv1.CustomResourceDefinitionNames{Kind: "XBucket"},
+			Versions: []cpext.CompositeResourceDefinitionVersion{{Name: "v1alpha1", Served: true}},
+		},
+	}}}
+	known := map[schema.GroupKind]bool{{Group: "s3.aws.upbound.io", Kind: "Bucket"}: true}
+
+	composition := func(name string, apiVersion string, bases ...string) unstructured.Unstructured {
+		resources := []interface{}{}
+		for _, base := range bases {
+			obj := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(base), &obj))
+			resources = append(resources, map[string]interface{}{"base": obj})
+		}
+
+		res := unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{
+			"compositeTypeRef": map[string]interface{}{"apiVersion": apiVersion, "kind": "XBucket"},
+			"resources":        resources,
+		}}}
+		res.SetName(name)
+		return res
+	}
+
+	pipeline := composition("pipeline", "example.org/v1alpha1")
+	pipeline.Object["spec"].(map[string]interface{})["mode"] = "Pipeline"
+	pipeline.Object["spec"].(map[string]interface{})["pipeline"] = []interface{}{
+		map[string]interface{}{"step": "patch", "functionRef": map[string]interface{}{"name": "function-patch-and-transform"}},
+		map[string]interface{}{"step": "render", "functionRef": map[string]interface{}{"name": "function-go-templating"}},
+		map[string]interface{}{"step": "ready", "functionRef": map[string]interface{}{"name": "function-auto-ready"}},
+	}
+	functions := []unstructured.Unstructured{
+		function("function-patch-and-transform", "xpkg.upbound.io/crossplane-contrib/function-patch-and-transform:v0.7.0",
+			cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "True", "HealthyPackageRevision")),
+		function("function-go-templating", "xpkg.upbound.io/crossplane-contrib/function-go-templating:v0.5.0",
+			cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "False", "UnhealthyPackageRevision")),
+	}
+
+	issues := compositionIssues([]unstructured.Unstructured{
+		composition("ok", "example.org/v1alpha1",
+			`{"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "Bucket"}`,
+			`{"apiVersion": "v1", "kind": "ConfigMap"}`,
+		),
+		composition("missing", "example.org/v1alpha1", `{"apiVersion": "rds.aws.upbound.io/v1beta1", "kind": "Instance"}`),
+		composition("unserved", "example.org/v1"),
+		pipeline,
+	}, xrds, known, functions, false)
+
+	require.Len(t, issues, 3)
+	assert.Equal(t, "missing", issues[0].Name)
+	assert.Equal(t, "resource #0: kind rds.aws.upbound.io/v1beta1 Instance is not installed", issues[0].Problem)
+	assert.Equal(t, "unserved", issues[1].Name)
+	assert.Equal(t, "composite type example.org/v1 XBucket is not served", issues[1].Problem)
+	assert.Equal(t, "pipeline", issues[2].Name)
+	assert.Equal(t, "function function-auto-ready is not installed; function function-go-templating is not healthy", issues[2].Problem)
+
+	issues = compositionIssues([]unstructured.Unstructured{pipeline}, xrds, known, nil, true)
+	assert.Empty(t, issues, "functions hidden from the viewer are reported as forbidden instead")
+}
@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/crossplane"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	descResources = prometheus.NewDesc(
+		"komoplane_resources",
+		"Number of claims, XRs and MRs, by kind, namespace, provider and condition status",
+		[]string{"cluster", "class", "group", "version", "kind", "namespace", "provider", "ready", "synced"}, nil,
+	)
+	descProviderCondition = prometheus.NewDesc(
+		"komoplane_provider_condition",
+		"Conditions of provider packages, like Installed and Healthy",
+		[]string{"cluster", "provider", "package", "condition", "status"}, nil,
+	)
+	descXRDCondition = prometheus.NewDesc(
+		"komoplane_xrd_condition",
+		"Conditions of XRDs, like Established and Offered",
+		[]string{"cluster", "xrd", "condition", "status"}, nil,
+	)
+)
+
+// resourcesCollector calculates the metrics from tracked resources at the time of scraping, for clusters that are connected already
+type resourcesCollector struct {
+	clusters *Clusters
+}
+
+func (r *resourcesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descResources
+	ch <- descProviderCondition
+	ch <- descXRDCondition
+}
+
+func (r *resourcesCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, data := range r.clusters.Initialized() {
+		collectCluster(data, ch)
+	}
+}
+
+func collectCluster(data *Controller, ch chan<- prometheus.Metric) {
+	providers, err := data.providersByKind(data.ctx)
+	if err != nil {
+		log.Warnf("Failed to map resources to providers for metrics in cluster %s: %v", data.Cluster, err)
+	}
+
+	for _, class := range []tracker.Class{tracker.ClassClaim, tracker.ClassComposite, tracker.ClassManaged} {
+		counts := map[[9]string]int{}
+		for _, item := range data.Tracker.List(data.ctx, class) {
+			gvk := item.GroupVersionKind()
+			key := [9]string{
+				data.Cluster, string(class), gvk.Group, gvk.Version, gvk.Kind, item.GetNamespace(),
+				providers[gvk.GroupKind()],
+				conditionStatus(&item, xpv1.TypeReady),
+				conditionStatus(&item, xpv1.TypeSynced),
+			}
+			counts[key]++
+		}
+
+		for key, count := range counts {
+			ch <- prometheus.MustNewConstMetric(descResources, prometheus.GaugeValue, float64(count), key[:]...)
+		}
+	}
+
+	for _, prov := range data.Tracker.List(data.ctx, tracker.ClassProvider) {
+		pkg, _, _ := unstructured.NestedString(prov.Object, "spec", "package")
+		for _, typ := range []xpv1.ConditionType{cpv1.TypeInstalled, cpv1.TypeHealthy} {
+			ch <- prometheus.MustNewConstMetric(descProviderCondition, prometheus.GaugeValue, 1,
+				data.Cluster, prov.GetName(), pkg, string(typ), conditionStatus(&prov, typ))
+		}
+	}
+
+	for _, xrd := range data.Tracker.List(data.ctx, tracker.ClassXRD) {
+		types := []xpv1.ConditionType{cpext.TypeEstablished}
+		if _, found, _ := unstructured.NestedMap(xrd.Object, "spec", "claimNames"); found {
+			types = append(types, cpext.TypeOffered)
+		}
+
+		for _, typ := range types {
+			ch <- prometheus.MustNewConstMetric(descXRDCondition, prometheus.GaugeValue, 1,
+				data.Cluster, xrd.GetName(), string(typ), conditionStatus(&xrd, typ))
+		}
+	}
+}
+
+var httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "komoplane",
+	Name:      "http_request_duration_seconds",
+	Help:      "Latency of komoplane API requests",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "code"})
+
+func measureLatency(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		// the response is not written yet for the failed request, echo's error handler does it later
+		code := c.Response().Status
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			code = httpErr.Code
+		} else if err != nil {
+			code = http.StatusInternalServerError
+		}
+		httpDuration.WithLabelValues(c.Request().Method, c.Path(), strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func configureMetrics(clusters *Clusters, eng *echo.Echo, token string) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpDuration,
+		&resourcesCollector{clusters: clusters},
+	)
+
+	if err := crossplane.RegisterMetrics(reg); err != nil {
+		log.Warnf("Failed to register Kubernetes client metrics: %v", err)
+	}
+
+	eng.Use(measureLatency)
+	eng.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(reg, promhttp.HandlerOpts{})), auth.BearerToken(token))
+}
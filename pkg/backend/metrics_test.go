@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasureLatency(t *testing.T) {
+	eng := echo.New()
+	eng.Use(measureLatency)
+	eng.GET("/test/ok", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	eng.GET("/test/missing", func(c echo.Context) error { return echo.NewHTTPError(http.StatusNotFound) })
+	eng.GET("/test/failed", func(c echo.Context) error { return errors.New("boom") })
+
+	for _, path := range []string{"/test/ok", "/test/missing", "/test/failed"} {
+		eng.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, uint64(1), sampleCount(t, "/test/ok", "200"))
+	assert.Equal(t, uint64(1), sampleCount(t, "/test/missing", "404"))
+	assert.Equal(t, uint64(1), sampleCount(t, "/test/failed", "500"), "the error becomes 500 later")
+	assert.Equal(t, uint64(0), sampleCount(t, "/test/failed", "200"))
+}
+
+func sampleCount(t *testing.T, route string, code string) uint64 {
+	metric := &dto.Metric{}
+	require.NoError(t, httpDuration.WithLabelValues(http.MethodGet, route, code).(prometheus.Histogram).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
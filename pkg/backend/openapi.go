@@ -0,0 +1,546 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/graph"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	v12 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type jsonObj = map[string]interface{}
+
+// routeDoc describes the route for OpenAPI document, parameters are the names from openAPIParams
+type routeDoc struct {
+	summary     string
+	description string
+	tag         string
+	params      []string
+	response    jsonObj
+	contentType string // application/json if empty
+}
+
+func ref(name string) jsonObj {
+	return jsonObj{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(schema jsonObj) jsonObj {
+	return jsonObj{"type": "array", "items": schema}
+}
+
+var (
+	listParams      = []string{"limit", "continue", "labelSelector", "listNamespace", "listKind", "provider", "providerConfig", "ready", "synced", "listName", "sort", "view"}
+	resourcesParams = []string{"limit", "continue", "labelSelector", "listNamespace", "listKind", "ready", "synced", "listName", "sort"}
+	listResponse    = jsonObj{"oneOf": []jsonObj{ref("ObjectList"), ref("SummaryList")}}
+	fullParams      = []string{"full"}
+	expandParams    = []string{"full", "depth"}
+)
+
+const graphDescription = "Nodes are the claim, XRs, MRs, composition, XRD, ProviderConfigs, providers and connection Secrets, " +
+	"starting from the topmost parent of the object, each with its status. Edges tell the relation between nodes."
+
+// routeDocs has to cover all the routes from configureRoutes, that is checked by tests
+var routeDocs = map[string]routeDoc{
+	"GET /status": {
+		summary: "Version and state of komoplane and the cluster", tag: "status", response: ref("StatusInfo"),
+	},
+	"GET /auth/user": {
+		summary: "Authenticated user, null when authentication is off", tag: "status", response: ref("User"),
+	},
+	"GET /api/clusters": {
+		summary: "Clusters that komoplane can work with", tag: "status", response: arrayOf(ref("Cluster")),
+	},
+	"GET /api/events/:name": {
+		summary: "Events of cluster-scoped object", tag: "events", params: []string{"eventKind"}, response: ref("ObjectList"),
+	},
+	"GET /api/events/:namespace/:name": {
+		summary: "Events of namespaced object", tag: "events", params: []string{"eventKind"}, response: ref("ObjectList"),
+	},
+	"GET /api/stream": {
+		summary:     "Stream of changes of claims, XRs, MRs, providers, XRDs and compositions",
+		description: "Server-Sent Events, with event type being `added`, `updated` or `deleted` and data being `Event` JSON. Comments are sent as heartbeat.",
+		tag:         "events", params: []string{"streamKind", "streamNamespace", "streamName"}, response: ref("Event"), contentType: "text/event-stream",
+	},
+	"GET /api/providers": {
+		summary: "List of providers", tag: "providers", response: ref("ObjectList"),
+	},
+	"GET /api/providers/:name": {
+		summary: "Provider", tag: "providers", response: ref("Object"),
+	},
+	"GET /api/providers/:name/events": {
+		summary: "Events of provider", tag: "providers", response: ref("ObjectList"),
+	},
+	"GET /api/providers/:name/configs": {
+		summary: "ProviderConfigs of provider", tag: "providers", response: ref("ObjectList"),
+	},
+	"GET /api/providers/:name/resources": {
+		summary:     "Managed resources of provider",
+		description: "Summaries of all managed resources which kinds are installed by the provider, to assess the impact of changing the provider or its credentials.",
+		tag:         "providers", params: resourcesParams, response: ref("SummaryList"),
+	},
+	"GET /api/providers/:name/configs/:config/resources": {
+		summary:     "Managed resources of provider that use the ProviderConfig",
+		description: "Summaries of managed resources of the provider that reference the ProviderConfig in `spec.providerConfigRef`.",
+		tag:         "providers", params: resourcesParams, response: ref("SummaryList"),
+	},
+	"GET /api/providers/:name/package": {
+		summary: "Package source, revisions, health and dependencies of provider", tag: "providers", response: ref("PackageInfo"),
+	},
+	"GET /api/providerconfigs": {
+		summary:     "Credential sources and usage of ProviderConfigs",
+		description: "For each ProviderConfig, tells where credentials come from, whether the referenced Secret and key exist, and how many resources use it. Secret values are never returned. Kinds that failed to load are listed as warnings.",
+		tag:         "providers", params: []string{"provider"}, response: ref("ProviderConfigReport"),
+	},
+	"GET /api/diagnostics/secrets": {
+		summary:     "Check of Secrets referenced by claims, composite and managed resources and ProviderConfigs",
+		description: "Follows connection secret refs, published connection details and `*SecretRef` fields of the spec, telling whether referenced Secrets and keys exist. Secret values are never returned.",
+		tag:         "diagnostics", params: []string{"problems"}, response: ref("SecretReport"),
+	},
+	"GET /api/diagnostics/deletions": {
+		summary:     "Claims, composite and managed resources stuck in deletion",
+		description: "For each object being deleted, lists its finalizers with the controllers expected to remove them, child resources that still exist, health of the owning provider and the reasons why deletion is blocked. The longest deleting objects first. Kinds hidden by RBAC are listed as forbidden, the provider state is `Unknown` when providers are among them.",
+		tag:         "diagnostics", response: ref("DeletionReport"),
+	},
+	"GET /api/graph/:group/:version/:kind/:name": {
+		summary:     "Relationship graph around cluster-scoped claim, composite or managed resource",
+		description: graphDescription,
+		tag:         "diagnostics", params: []string{"format"}, response: ref("Graph"),
+	},
+	"GET /api/graph/:group/:version/:kind/:namespace/:name": {
+		summary:     "Relationship graph around namespaced claim, composite or managed resource",
+		description: graphDescription,
+		tag:         "diagnostics", params: []string{"format"}, response: ref("Graph"),
+	},
+	"GET /api/health-report": {
+		summary:     "What is wrong with Crossplane in the cluster",
+		description: "Aggregates unhealthy packages, XRDs that are not established or offered, compositions referencing missing kinds or missing and unhealthy functions, not ready claims, composite and managed resources grouped by reason, managed resources stuck in deletion and the ones not ready for longer than the threshold.",
+		tag:         "diagnostics", params: []string{"staleAfter"}, response: ref("HealthReport"),
+	},
+	"GET /api/functions": {
+		summary: "List of functions with their revisions", tag: "packages", response: arrayOf(ref("PackageInfo")),
+	},
+	"GET /api/functions/:name": {
+		summary: "Function with its revisions", tag: "packages", response: ref("PackageInfo"),
+	},
+	"GET /api/configurations": {
+		summary: "List of configurations with their revisions", tag: "packages", response: arrayOf(ref("PackageInfo")),
+	},
+	"GET /api/configurations/:name": {
+		summary:     "Configuration with its revisions",
+		description: "Objects installed by the active revision, like XRDs and Compositions, are listed in `objects`.",
+		tag:         "packages", response: ref("PackageInfo"),
+	},
+	"GET /api/dependencies": {
+		summary:     "Package dependency graph from Crossplane Lock",
+		description: "Each dependency has version constraints of the dependent package and the version that is actually installed. Dependencies that are missing or have unsuitable versions are listed in `unsatisfied`.",
+		tag:         "packages", params: []string{"package"}, response: ref("DependencyGraph"),
+	},
+	"GET /api/claims": {
+		summary: "List of claims", tag: "claims", params: listParams, response: listResponse,
+	},
+	"GET /api/claims/:group/:version/:kind/:namespace/:name": {
+		summary: "Claim", tag: "claims", params: expandParams, response: ref("ClaimFull"),
+	},
+	"POST /api/claims/:group/:version/:kind/:namespace/:name/:action": {
+		summary: "Pause, resume or reconcile claim", description: "Requires komoplane started with `--enable-actions`. Cross-site requests are refused, scripts have to send `X-Requested-By` header.", tag: "claims", response: ref("Object"),
+	},
+	"GET /api/managed": {
+		summary: "List of managed resources", tag: "managed", params: listParams, response: listResponse,
+	},
+	"GET /api/managed/:group/:version/:kind/:name": {
+		summary: "Cluster-scoped managed resource", tag: "managed", params: fullParams, response: ref("ManagedFull"),
+	},
+	"GET /api/managed/:group/:version/:kind/:namespace/:name": {
+		summary: "Namespaced managed resource", tag: "managed", params: fullParams, response: ref("ManagedFull"),
+	},
+	"POST /api/managed/:group/:version/:kind/:name/:action": {
+		summary: "Pause, resume or reconcile cluster-scoped managed resource", description: "Requires komoplane started with `--enable-actions`. Cross-site requests are refused, scripts have to send `X-Requested-By` header.", tag: "managed", response: ref("Object"),
+	},
+	"POST /api/managed/:group/:version/:kind/:namespace/:name/:action": {
+		summary: "Pause, resume or reconcile namespaced managed resource", description: "Requires komoplane started with `--enable-actions`. Cross-site requests are refused, scripts have to send `X-Requested-By` header.", tag: "managed", response: ref("Object"),
+	},
+	"GET /api/composite": {
+		summary: "List of composite resources", tag: "composite", params: listParams, response: listResponse,
+	},
+	"GET /api/composite/:group/:version/:kind/:name": {
+		summary: "Cluster-scoped composite resource", tag: "composite", params: expandParams, response: ref("CompositeFull"),
+	},
+	"GET /api/composite/:group/:version/:kind/:namespace/:name": {
+		summary: "Namespaced composite resource", tag: "composite", params: expandParams, response: ref("CompositeFull"),
+	},
+	"POST /api/composite/:group/:version/:kind/:name/:action": {
+		summary: "Pause, resume or reconcile cluster-scoped composite resource", description: "Requires komoplane started with `--enable-actions`. Cross-site requests are refused, scripts have to send `X-Requested-By` header.", tag: "composite", response: ref("Object"),
+	},
+	"POST /api/composite/:group/:version/:kind/:namespace/:name/:action": {
+		summary: "Pause, resume or reconcile namespaced composite resource", description: "Requires komoplane started with `--enable-actions`. Cross-site requests are refused, scripts have to send `X-Requested-By` header.", tag: "composite", response: ref("Object"),
+	},
+	"GET /api/compositions": {
+		summary: "List of compositions", tag: "compositions", response: ref("ObjectList"),
+	},
+	"GET /api/composition/:name": {
+		summary: "Composition", tag: "compositions", response: ref("Object"),
+	},
+	"GET /api/composition/:name/pipeline": {
+		summary:     "Function pipeline of composition",
+		description: "Resolves each step of `Pipeline` mode composition to installed Function package and summarizes its input. Functions that are missing or unhealthy are listed separately.",
+		tag:         "compositions", response: ref("CompositionPipeline"),
+	},
+	"GET /api/xrds": {
+		summary: "List of composite resource definitions", tag: "xrds", response: ref("ObjectList"),
+	},
+}
+
+func queryParam(name string, description string, schema jsonObj) jsonObj {
+	return jsonObj{"name": name, "in": "query", "description": description, "schema": schema}
+}
+
+var (
+	str     = jsonObj{"type": "string"}
+	integer = jsonObj{"type": "integer"}
+	status  = jsonObj{"type": "string", "enum": []string{"True", "False", "Unknown"}}
+)
+
+// openAPIParams are reusable parameters, keyed by the name used in routeDocs
+var openAPIParams = map[string]jsonObj{
+	"limit":           queryParam("limit", "Maximum number of items to return", integer),
+	"continue":        queryParam("continue", "Token from `metadata.continue` of previous page", str),
+	"labelSelector":   queryParam("labelSelector", "Kubernetes label selector, like `team=a,env!=prod`", str),
+	"listNamespace":   queryParam("namespace", "Only items from the namespace", str),
+	"listKind":        queryParam("kind", "Only items of the kind", str),
+	"provider":        queryParam("provider", "Only items installed by the provider", str),
+	"providerConfig":  queryParam("providerConfig", "Only managed resources referencing the ProviderConfig", str),
+	"ready":           queryParam("ready", "Only items with Ready condition of the status", status),
+	"synced":          queryParam("synced", "Only items with Synced condition of the status", status),
+	"listName":        queryParam("name", "Only items with name containing the substring", str),
+	"sort":            queryParam("sort", "Sort field, prefix with `-` for descending order", jsonObj{"type": "string", "enum": []string{"name", "-name", "namespace", "-namespace", "kind", "-kind", "age", "-age"}}),
+	"view":            queryParam("view", "`summary` returns compact `SummaryList` instead of full objects", jsonObj{"type": "string", "enum": []string{viewFull, viewSummary}}),
+	"full":            queryParam("full", "Any non-empty value adds related resources to response, like composite resource, managed resources, composition and root causes of problems", str),
+	"problems":        queryParam("problems", "Any non-empty value leaves only the references with problems", str),
+	"staleAfter":      queryParam("staleAfter", "Duration after which not ready resources are stale and deleting ones are stuck, like `30m`, defaults to `1h`", str),
+	"depth":           queryParam("depth", "With `full`, how many levels of nested composite resources to load with their own resources, from 0 to 10", integer),
+	"format":          queryParam("format", "Response format: `json` (default), `dot` for Graphviz or `mermaid` for Mermaid flowchart", str),
+	"package":         queryParam("package", "Only the package, given by its source or revision name, with the packages that depend on it and its own dependencies", str),
+	"eventKind":       queryParam("kind", "Kind of the object", str),
+	"streamKind":      queryParam("kind", "Only changes of objects of the kind, or of the class like `managed`, `composite` or `claim`", str),
+	"streamNamespace": queryParam("namespace", "Only changes of objects in the namespace", str),
+	"streamName":      queryParam("name", "Only changes of objects with the name", str),
+}
+
+var pathParamDocs = map[string]string{
+	"group":     "API group of the resource",
+	"version":   "API version of the resource",
+	"kind":      "Kind of the resource",
+	"namespace": "Namespace of the resource",
+	"name":      "Name of the resource",
+	"config":    "Name of the ProviderConfig",
+	"action":    "One of `pause`, `resume`, `reconcile`",
+}
+
+var pathParamRe = regexp.MustCompile(`:(\w+)`)
+
+// openAPIDoc describes the routes of the server that have docs
+func openAPIDoc(routes []*echo.Route, version string) jsonObj {
+	paths := jsonObj{}
+	tags := map[string]bool{}
+	for _, route := range routes {
+		doc, found := routeDocs[route.Method+" "+route.Path]
+		if !found {
+			continue
+		}
+
+		params := []jsonObj{}
+		for _, match := range pathParamRe.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, jsonObj{
+				"name": match[1], "in": "path", "required": true, "description": pathParamDocs[match[1]], "schema": str,
+			})
+		}
+		for _, name := range doc.params {
+			params = append(params, jsonObj{"$ref": "#/components/parameters/" + name})
+		}
+		if strings.HasPrefix(route.Path, "/api/") {
+			params = append(params, jsonObj{"$ref": "#/components/parameters/cluster"})
+		}
+
+		contentType := doc.contentType
+		if contentType == "" {
+			contentType = echo.MIMEApplicationJSON
+		}
+
+		op := jsonObj{
+			"summary":     doc.summary,
+			"operationId": operationID(route),
+			"tags":        []string{doc.tag},
+			"parameters":  params,
+			"responses": jsonObj{
+				"200": jsonObj{
+					"description": "OK",
+					"content":     jsonObj{contentType: jsonObj{"schema": doc.response}},
+				},
+				"default": jsonObj{"description": "Error", "content": jsonObj{echo.MIMEApplicationJSON: jsonObj{"schema": ref("Error")}}},
+			},
+		}
+		if doc.description != "" {
+			op["description"] = doc.description
+		}
+
+		path := pathParamRe.ReplaceAllString(route.Path, "{$1}")
+		if _, found := paths[path]; !found {
+			paths[path] = jsonObj{}
+		}
+		paths[path].(jsonObj)[strings.ToLower(route.Method)] = op
+		tags[doc.tag] = true
+	}
+
+	tagList := []jsonObj{}
+	for tag := range tags {
+		tagList = append(tagList, jsonObj{"name": tag})
+	}
+	sort.Slice(tagList, func(i, j int) bool {
+		return tagList[i]["name"].(string) < tagList[j]["name"].(string)
+	})
+
+	params := jsonObj{
+		"cluster": jsonObj{
+			"name": headerCluster, "in": "header", "schema": str,
+			"description": "Cluster to work with, the default one if not set. Alternatively, prefix the path with `/api/clusters/{cluster}`",
+		},
+	}
+	for name, param := range openAPIParams {
+		params[name] = param
+	}
+
+	return jsonObj{
+		"openapi": "3.0.3",
+		"info": jsonObj{
+			"title":       "komoplane API",
+			"description": "API of komoplane, the dashboard for Crossplane resources",
+			"version":     version,
+		},
+		"tags":  tagList,
+		"paths": paths,
+		"components": jsonObj{
+			"parameters": params,
+			"schemas":    openAPISchemas(),
+		},
+	}
+}
+
+// operationID makes the name for generated client methods, like getClaimsGroupVersionKindNamespaceName
+func operationID(route *echo.Route) string {
+	res := strings.ToLower(route.Method)
+	for _, part := range strings.Split(route.Path, "/") {
+		part = strings.TrimPrefix(part, ":")
+		if part == "" || part == "api" {
+			continue
+		}
+		res += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return res
+}
+
+func openAPISchemas() jsonObj {
+	object := jsonObj{
+		"type":        "object",
+		"description": "Kubernetes object",
+		"properties": jsonObj{
+			"apiVersion": str,
+			"kind":       str,
+			"metadata":   jsonObj{"type": "object"},
+			"spec":       jsonObj{"type": "object"},
+			"status":     jsonObj{"type": "object"},
+			"forbidden":  jsonObj{"type": "boolean", "description": "The viewer is not allowed to read the object"},
+		},
+		"additionalProperties": true,
+	}
+
+	withFields := func(description string, fields jsonObj) jsonObj {
+		return jsonObj{
+			"description": description,
+			"allOf":       []jsonObj{ref("Object"), {"type": "object", "properties": fields}},
+		}
+	}
+
+	composite := jsonObj{
+		"composition":            ref("Object"),
+		"managedResources":       arrayOf(ref("Object")),
+		"managedResourcesXRs":    arrayOf(ref("ObjectReference")),
+		"managedResourcesClaims": arrayOf(ref("ObjectReference")),
+		"rootCauses":             arrayOf(ref("RootCause")),
+		"warnings":               arrayOf(schemaOf(reflect.TypeOf(Warning{}))),
+	}
+
+	compositeFull := jsonObj{}
+	for k, v := range composite {
+		compositeFull[k] = v
+	}
+	compositeFull["claim"] = ref("Object")
+	compositeFull["parentXR"] = ref("Object")
+
+	return jsonObj{
+		"Object": object,
+		"ObjectList": jsonObj{
+			"type": "object",
+			"properties": jsonObj{
+				"metadata": ref("ListMeta"),
+				"items":    arrayOf(ref("Object")),
+				"forbidden": jsonObj{
+					"type": "array", "items": str, "description": "Kinds hidden from the viewer by RBAC",
+				},
+				"warnings": jsonObj{
+					"type": "array", "items": schemaOf(reflect.TypeOf(Warning{})), "description": "Kinds that failed to load, the list is partial",
+				},
+			},
+		},
+		"ClaimFull": withFields("Claim, the related resources are filled with `full` parameter", jsonObj{
+			"compositeResource": withFields("Composite resource of the claim", composite),
+			"composition":       ref("Object"),
+			"rootCauses":        arrayOf(ref("RootCause")),
+			"warnings":          arrayOf(schemaOf(reflect.TypeOf(Warning{}))),
+		}),
+		"CompositeFull": withFields("Composite resource, the related resources are filled with `full` parameter", compositeFull),
+		"ManagedFull": withFields("Managed resource, the related resources are filled with `full` parameter", jsonObj{
+			"provConfig": ref("Object"),
+			"composite":  ref("Object"),
+		}),
+		"ObjectReference": schemaOf(reflect.TypeOf(v12.ObjectReference{})),
+		"Error": jsonObj{
+			"type":       "object",
+			"properties": jsonObj{"message": str},
+		},
+		"ListMeta":             schemaOf(reflect.TypeOf(metav1.ListMeta{})),
+		"SummaryList":          schemaOf(reflect.TypeOf(SummaryList{})),
+		"RootCause":            schemaOf(reflect.TypeOf(RootCause{})),
+		"CompositionPipeline":  schemaOf(reflect.TypeOf(CompositionPipeline{})),
+		"PackageInfo":          schemaOf(reflect.TypeOf(PackageInfo{})),
+		"DependencyGraph":      schemaOf(reflect.TypeOf(DependencyGraph{})),
+		"ProviderConfigReport": schemaOf(reflect.TypeOf(ProviderConfigReport{})),
+		"ProviderConfigInfo":   schemaOf(reflect.TypeOf(ProviderConfigInfo{})),
+		"SecretReport":         schemaOf(reflect.TypeOf(SecretReport{})),
+		"HealthReport":         schemaOf(reflect.TypeOf(HealthReport{})),
+		"DeletionReport":       schemaOf(reflect.TypeOf(DeletionReport{})),
+		"DeletionInfo":         schemaOf(reflect.TypeOf(DeletionInfo{})),
+		"Graph":                schemaOf(reflect.TypeOf(graph.Graph{})),
+		"StatusInfo":           schemaOf(reflect.TypeOf(StatusInfo{})),
+		"Cluster":              schemaOf(reflect.TypeOf(Cluster{})),
+		"User":                 schemaOf(reflect.TypeOf(auth.User{})),
+		"Event":                schemaOf(reflect.TypeOf(tracker.Event{})),
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf describes Go type by its JSON encoding
+func schemaOf(t reflect.Type) jsonObj {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType || t == reflect.TypeOf(metav1.Time{}) {
+		return jsonObj{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return jsonObj{"type": "string"}
+	case reflect.Bool:
+		return jsonObj{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return jsonObj{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonObj{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return arrayOf(schemaOf(t.Elem()))
+	case reflect.Map:
+		return jsonObj{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := jsonObj{}
+		addStructFields(t, props)
+		return jsonObj{"type": "object", "properties": props}
+	default:
+		return jsonObj{}
+	}
+}
+
+func addStructFields(t reflect.Type, props jsonObj) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			addStructFields(field.Type, props)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = schemaOf(field.Type)
+	}
+}
+
+// swagger-ui is pinned to exact version, and the page is sandboxed, so that its scripts can't use viewer's session
+const swaggerUI = "https://unpkg.com/swagger-ui-dist@5.17.14/"
+
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>komoplane API</title>
+  <link rel="stylesheet" href="` + swaggerUI + `swagger-ui.css" crossorigin="anonymous">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="` + swaggerUI + `swagger-ui-bundle.js" crossorigin="anonymous"></script>
+<script>%s</script>
+</body>
+</html>
+`
+
+// apiDocsHTML inlines the document into the page, as the sandboxed page is not allowed to fetch anything
+func apiDocsHTML(doc jsonObj) (string, string, error) {
+	spec, err := json.Marshal(doc) // escapes HTML characters, so it can't end the script
+	if err != nil {
+		return "", "", err
+	}
+
+	script := `window.ui = SwaggerUIBundle({spec: ` + string(spec) + `, dom_id: "#swagger-ui"});`
+	hash := sha256.Sum256([]byte(script))
+	csp := "sandbox allow-scripts; default-src 'none'; connect-src 'none'; img-src data:; " +
+		"style-src " + swaggerUI + " 'unsafe-inline'; " +
+		"script-src " + swaggerUI + " 'sha256-" + base64.StdEncoding.EncodeToString(hash[:]) + "'"
+	return fmt.Sprintf(apiDocsPage, script), csp, nil
+}
+
+func configureAPIDocs(eng *echo.Echo, version string) {
+	eng.GET("/api-docs", func(c echo.Context) error { // https://github.com/OAI/OpenAPI-Specification/search?q=api-docs
+		page, csp, err := apiDocsHTML(openAPIDoc(eng.Routes(), version))
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set("Content-Security-Policy", csp)
+		return c.HTML(http.StatusOK, page)
+	})
+
+	eng.GET("/api-docs/openapi.json", func(c echo.Context) error {
+		return c.JSONPretty(http.StatusOK, openAPIDoc(eng.Routes(), version), "  ")
+	})
+}
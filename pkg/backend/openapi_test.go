@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI_AllRoutesDocumented(t *testing.T) {
+	eng := echo.New()
+	configureRoutes(testClusters(), eng)
+
+	for _, route := range eng.Routes() {
+		if route.Path == "/api-docs" || strings.HasPrefix(route.Path, "/api-docs/") {
+			continue
+		}
+		_, found := routeDocs[route.Method+" "+route.Path]
+		assert.True(t, found, "route is missing in OpenAPI docs: %s %s", route.Method, route.Path)
+	}
+}
+
+func TestOpenAPI_Serve(t *testing.T) {
+	eng := echo.New()
+	configureRoutes(testClusters(), eng)
+
+	rec := httptest.NewRecorder()
+	eng.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-docs/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	doc := struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths["/api/claims/{group}/{version}/{kind}/{namespace}/{name}"], "get")
+	assert.Contains(t, doc.Paths["/api/managed/{group}/{version}/{kind}/{name}/{action}"], "post")
+	assert.Contains(t, string(rec.Body.Bytes()), "compositeResource")
+
+	rec = httptest.NewRecorder()
+	eng.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"openapi":"3.0.3"`, "the document is inlined")
+	assert.Contains(t, rec.Body.String(), "swagger-ui-dist@5.17.14/")
+	assert.Contains(t, rec.Header().Get("Content-Security-Policy"), "sandbox allow-scripts;")
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := schemaOf(reflect.TypeOf(RootCause{}))
+	props := schema["properties"].(jsonObj)
+	assert.Contains(t, props, "name", "embedded struct fields are inlined")
+	assert.Contains(t, props, "depth")
+	assert.Equal(t, arrayOf(jsonObj{"type": "string"}), props["events"])
+}
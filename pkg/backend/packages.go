@@ -0,0 +1,282 @@
+package backend
+
+import (
+	"net/http"
+	"sort"
+
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/labstack/echo/v4"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// labelPackage is put by Crossplane onto package revisions, pointing to their package
+const labelPackage = "pkg.crossplane.io/package"
+
+// PackageInfo is a summary of Crossplane package (Provider, Function or Configuration) and its revisions
+type PackageInfo struct {
+	Kind            string            `json:"kind"`
+	Name            string            `json:"name"`
+	Package         string            `json:"package"`
+	Version         string            `json:"version,omitempty"`
+	CurrentRevision string            `json:"currentRevision,omitempty"`
+	ActiveRevision  string            `json:"activeRevision,omitempty"`
+	Installed       *ConditionSummary `json:"installed,omitempty"`
+	Healthy         *ConditionSummary `json:"healthy,omitempty"`
+	Dependencies    *DependencyStatus `json:"dependencies,omitempty"` // of the active revision
+	Objects         []ObjectRef       `json:"objects"`                // installed by the active revision, like XRDs and Compositions
+	Revisions       []RevisionInfo    `json:"revisions"`              // the newest first
+}
+
+type DependencyStatus struct {
+	SkipResolution bool  `json:"skipResolution,omitempty"`
+	Found          int64 `json:"found"`
+	Installed      int64 `json:"installed"`
+	Invalid        int64 `json:"invalid"`
+}
+
+type RevisionInfo struct {
+	Name              string            `json:"name"`
+	Image             string            `json:"image"`
+	Revision          int64             `json:"revision"`
+	DesiredState      string            `json:"desiredState"`
+	Healthy           *ConditionSummary `json:"healthy,omitempty"`
+	CreationTimestamp metav1.Time       `json:"creationTimestamp"`
+}
+
+func (c *Controller) GetFunctions(ec echo.Context) error {
+	functions, err := c.APIv1.Functions().List(c.reqCtx(ec))
+	if forbidden(err, "functions") || k8sErrors.IsNotFound(err) { // older Crossplane has no functions
+		functions, err = &unstructured.UnstructuredList{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.functionRevisions(ec)
+	if err != nil {
+		return err
+	}
+
+	return ec.JSONPretty(http.StatusOK, packageInfos(functions.Items, revisions), "  ")
+}
+
+func (c *Controller) GetFunction(ec echo.Context) error {
+	function, err := c.APIv1.Functions().Get(c.reqCtx(ec), ec.Param("name"))
+	if k8sErrors.IsNotFound(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "function not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.functionRevisions(ec)
+	if err != nil {
+		return err
+	}
+
+	return ec.JSONPretty(http.StatusOK, packageInfo(function, revisions), "  ")
+}
+
+func (c *Controller) functionRevisions(ec echo.Context) ([]unstructured.Unstructured, error) {
+	revisions, err := c.APIv1.FunctionRevisions().List(c.reqCtx(ec))
+	if forbidden(err, "function revisions") || k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return revisions.Items, nil
+}
+
+func (c *Controller) GetConfigurations(ec echo.Context) error {
+	configurations, err := c.APIv1.Configurations().List(c.reqCtx(ec))
+	if forbidden(err, "configurations") {
+		configurations, err = &cpv1.ConfigurationList{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	items := []unstructured.Unstructured{}
+	for i := range configurations.Items {
+		obj, err := toUnstructured(&configurations.Items[i], cpv1.ConfigurationGroupVersionKind)
+		if err != nil {
+			return err
+		}
+		items = append(items, *obj)
+	}
+
+	revisions, err := c.configurationRevisions(ec)
+	if err != nil {
+		return err
+	}
+
+	return ec.JSONPretty(http.StatusOK, packageInfos(items, revisions), "  ")
+}
+
+func (c *Controller) GetConfiguration(ec echo.Context) error {
+	configuration, err := c.APIv1.Configurations().Get(c.reqCtx(ec), ec.Param("name"))
+	if k8sErrors.IsNotFound(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "configuration not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	obj, err := toUnstructured(configuration, cpv1.ConfigurationGroupVersionKind)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.configurationRevisions(ec)
+	if err != nil {
+		return err
+	}
+
+	return ec.JSONPretty(http.StatusOK, packageInfo(obj, revisions), "  ")
+}
+
+func (c *Controller) configurationRevisions(ec echo.Context) ([]unstructured.Unstructured, error) {
+	revisions, err := c.APIv1.ConfigurationRevisions().List(c.reqCtx(ec))
+	if forbidden(err, "configuration revisions") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := []unstructured.Unstructured{}
+	for i := range revisions.Items {
+		obj, err := toUnstructured(&revisions.Items[i], cpv1.ConfigurationRevisionGroupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *obj)
+	}
+	return res, nil
+}
+
+func (c *Controller) GetProviderPackage(ec echo.Context) error {
+	provider, err := c.APIv1.Providers().Get(c.reqCtx(ec), ec.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	obj, err := toUnstructured(provider, cpv1.ProviderGroupVersionKind)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.APIv1.ProviderRevisions().List(c.reqCtx(ec))
+	if forbidden(err, "provider revisions") {
+		revisions, err = &cpv1.ProviderRevisionList{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	items := []unstructured.Unstructured{}
+	for i := range revisions.Items {
+		rev, err := toUnstructured(&revisions.Items[i], cpv1.ProviderRevisionGroupVersionKind)
+		if err != nil {
+			return err
+		}
+		items = append(items, *rev)
+	}
+
+	return ec.JSONPretty(http.StatusOK, packageInfo(obj, items), "  ")
+}
+
+// toUnstructured also sets the kind, as API does not always fill it for typed objects
+func toUnstructured(obj runtime.Object, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &unstructured.Unstructured{Object: content}
+	res.SetGroupVersionKind(gvk)
+	return res, nil
+}
+
+func packageInfos(packages []unstructured.Unstructured, revisions []unstructured.Unstructured) []*PackageInfo {
+	res := []*PackageInfo{}
+	for i := range packages {
+		res = append(res, packageInfo(&packages[i], revisions))
+	}
+	return res
+}
+
+func packageInfo(pkg *unstructured.Unstructured, revisions []unstructured.Unstructured) *PackageInfo {
+	res := &PackageInfo{
+		Kind:      pkg.GetKind(),
+		Name:      pkg.GetName(),
+		Installed: conditionSummary(pkg, cpv1.TypeInstalled),
+		Healthy:   conditionSummary(pkg, cpv1.TypeHealthy),
+		Objects:   []ObjectRef{},
+		Revisions: []RevisionInfo{},
+	}
+	res.Package, _, _ = unstructured.NestedString(pkg.Object, "spec", "package")
+	res.CurrentRevision, _, _ = unstructured.NestedString(pkg.Object, "status", "currentRevision")
+
+	ident, _, _ := unstructured.NestedString(pkg.Object, "status", "currentIdentifier")
+	if ident == "" {
+		ident = res.Package
+	}
+	res.Version = packageVersion(ident)
+
+	skip, _, _ := unstructured.NestedBool(pkg.Object, "spec", "skipDependencyResolution")
+	for i := range revisions {
+		rev := &revisions[i]
+		if rev.GetLabels()[labelPackage] != pkg.GetName() {
+			continue
+		}
+
+		info := RevisionInfo{
+			Name:              rev.GetName(),
+			Healthy:           conditionSummary(rev, cpv1.TypeHealthy),
+			CreationTimestamp: rev.GetCreationTimestamp(),
+		}
+		info.Image, _, _ = unstructured.NestedString(rev.Object, "spec", "image")
+		info.Revision, _, _ = unstructured.NestedInt64(rev.Object, "spec", "revision")
+		info.DesiredState, _, _ = unstructured.NestedString(rev.Object, "spec", "desiredState")
+		res.Revisions = append(res.Revisions, info)
+
+		if info.DesiredState == string(cpv1.PackageRevisionActive) {
+			res.ActiveRevision = info.Name
+			res.Dependencies = &DependencyStatus{SkipResolution: skip}
+			res.Dependencies.Found, _, _ = unstructured.NestedInt64(rev.Object, "status", "foundDependencies")
+			res.Dependencies.Installed, _, _ = unstructured.NestedInt64(rev.Object, "status", "installedDependencies")
+			res.Dependencies.Invalid, _, _ = unstructured.NestedInt64(rev.Object, "status", "invalidDependencies")
+			res.Objects = revisionObjects(rev)
+		}
+	}
+
+	sort.Slice(res.Revisions, func(i, j int) bool {
+		return res.Revisions[i].Revision > res.Revisions[j].Revision
+	})
+
+	return res
+}
+
+func revisionObjects(rev *unstructured.Unstructured) []ObjectRef {
+	res := []ObjectRef{}
+	refs, _, _ := unstructured.NestedSlice(rev.Object, "status", "objectRefs")
+	for _, ref := range refs {
+		m, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		obj := ObjectRef{}
+		obj.APIVersion, _, _ = unstructured.NestedString(m, "apiVersion")
+		obj.Kind, _, _ = unstructured.NestedString(m, "kind")
+		obj.Name, _, _ = unstructured.NestedString(m, "name")
+		res = append(res, obj)
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"testing"
+
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func revision(pkg string, name string, rev int64, state cpv1.PackageRevisionDesiredState) unstructured.Unstructured {
+	obj := cpv1.ConfigurationRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{labelPackage: pkg}},
+	}
+	obj.Spec.Package = "xpkg.upbound.io/org/" + pkg + ":v" + name
+	obj.Spec.Revision = rev
+	obj.Spec.DesiredState = state
+	obj.Status.FoundDependencies = 2
+	obj.Status.InstalledDependencies = 1
+
+	res, err := toUnstructured(&obj, cpv1.ConfigurationRevisionGroupVersionKind)
+	if err != nil {
+		panic(err)
+	}
+
+	if state == cpv1.PackageRevisionActive {
+		res.Object["status"].(map[string]interface{})["objectRefs"] = []interface{}{
+			map[string]interface{}{"apiVersion": "apiextensions.crossplane.io/v1", "kind": "CompositeResourceDefinition", "name": "xnetworks.example.org"},
+			map[string]interface{}{"apiVersion": "apiextensions.crossplane.io/v1", "kind": "Composition", "name": "network-aws"},
+		}
+	}
+	return *res
+}
+
+func TestPackageInfo(t *testing.T) {
+	conf := cpv1.Configuration{ObjectMeta: metav1.ObjectMeta{Name: "platform"}}
+	conf.Spec.Package = "xpkg.upbound.io/org/platform:v2"
+	conf.Status.CurrentRevision = "platform-2"
+	conf.Status.CurrentIdentifier = "xpkg.upbound.io/org/platform:v2"
+	conf.Status.SetConditions(cpv1.Active(), cpv1.Unhealthy())
+	obj, err := toUnstructured(&conf, cpv1.ConfigurationGroupVersionKind)
+	require.NoError(t, err)
+
+	revisions := []unstructured.Unstructured{
+		revision("platform", "platform-1", 1, cpv1.PackageRevisionInactive),
+		revision("other", "other-1", 1, cpv1.PackageRevisionActive),
+		revision("platform", "platform-2", 2, cpv1.PackageRevisionActive),
+	}
+
+	res := packageInfo(obj, revisions)
+	assert.Equal(t, "Configuration", res.Kind)
+	assert.Equal(t, "v2", res.Version)
+	assert.Equal(t, "platform-2", res.ActiveRevision)
+	assert.Equal(t, "True", res.Installed.Status)
+	assert.Equal(t, "False", res.Healthy.Status)
+	assert.Equal(t, &DependencyStatus{Found: 2, Installed: 1}, res.Dependencies)
+
+	require.Len(t, res.Revisions, 2)
+	assert.Equal(t, "platform-2", res.Revisions[0].Name, "the newest first")
+	assert.Equal(t, "Inactive", res.Revisions[1].DesiredState)
+
+	require.Len(t, res.Objects, 2)
+	assert.Equal(t, "CompositeResourceDefinition", res.Objects[0].Kind)
+	assert.Equal(t, "network-aws", res.Objects[1].Name)
+}
+
+func TestPackageInfo_NoRevisions(t *testing.T) {
+	fn := diagObj("Function", "function-kcl")
+	fn.Object["spec"] = map[string]interface{}{"package": "xpkg.upbound.io/crossplane-contrib/function-kcl:v0.9.0"}
+
+	res := packageInfo(fn, nil)
+	assert.Equal(t, "v0.9.0", res.Version)
+	assert.Nil(t, res.Dependencies)
+	assert.Empty(t, res.Revisions)
+	assert.Nil(t, res.Healthy)
+}
@@ -0,0 +1,243 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/labstack/echo/v4"
+	v12 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CompositionPipeline describes the function steps of Composition in Pipeline mode
+type CompositionPipeline struct {
+	Composition string         `json:"composition"`
+	Mode        string         `json:"mode"`
+	Steps       []PipelineStep `json:"steps"`
+	Missing     []string       `json:"missing"`   // functions referenced by steps, but not installed
+	Unhealthy   []string       `json:"unhealthy"` // functions installed, but not healthy
+	Healthy     bool           `json:"healthy"`
+	Forbidden   bool           `json:"forbidden,omitempty"` // functions are not visible to the viewer, so their state is unknown
+}
+
+type PipelineStep struct {
+	Step     string        `json:"step"`
+	Function string        `json:"function"`
+	Input    *StepInput    `json:"input,omitempty"`
+	Package  *FunctionInfo `json:"package,omitempty"` // empty if function is not installed
+	Problem  string        `json:"problem,omitempty"`
+}
+
+type StepInput struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Summary    string `json:"summary"`
+}
+
+type FunctionInfo struct {
+	Package   string            `json:"package"`
+	Version   string            `json:"version,omitempty"`
+	Revision  string            `json:"revision,omitempty"`
+	Installed string            `json:"installed"`
+	Healthy   string            `json:"healthy"`
+	Condition *ConditionSummary `json:"condition,omitempty"` // the first problematic one, if any
+}
+
+func (c *Controller) GetCompositionPipeline(ec echo.Context) error {
+	name := ec.Param("name")
+
+	comp := &unstructured.Unstructured{}
+	ref := v12.ObjectReference{Name: name}
+	ref.SetGroupVersionKind(cpext.CompositionGroupVersionKind)
+	err := c.CRDs.Get(c.reqCtx(ec), comp, &ref)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "composition not found")
+		}
+		return err
+	}
+
+	functions, err := c.APIv1.Functions().List(c.reqCtx(ec))
+	denied := forbidden(err, "functions")
+	if denied || k8sErrors.IsNotFound(err) { // not allowed, or Crossplane is too old to have functions
+		functions, err = &unstructured.UnstructuredList{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	res := buildPipeline(comp, functions.Items, denied)
+	return ec.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func buildPipeline(comp *unstructured.Unstructured, functions []unstructured.Unstructured, denied bool) *CompositionPipeline {
+	steps, _, _ := unstructured.NestedSlice(comp.Object, "spec", "pipeline")
+	mode, _, _ := unstructured.NestedString(comp.Object, "spec", "mode")
+	if mode == "" && len(steps) > 0 { // newer Crossplane has Pipeline as the only mode
+		mode = "Pipeline"
+	} else if mode == "" {
+		mode = "Resources"
+	}
+
+	res := &CompositionPipeline{
+		Composition: comp.GetName(),
+		Mode:        mode,
+		Steps:       []PipelineStep{},
+		Missing:     []string{},
+		Unhealthy:   []string{},
+		Forbidden:   denied,
+	}
+
+	byName := map[string]*unstructured.Unstructured{}
+	for i := range functions {
+		byName[functions[i].GetName()] = &functions[i]
+	}
+
+	missing := map[string]bool{}
+	unhealthy := map[string]bool{}
+	for _, raw := range steps {
+		spec, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		step := PipelineStep{}
+		step.Step, _, _ = unstructured.NestedString(spec, "step")
+		step.Function, _, _ = unstructured.NestedString(spec, "functionRef", "name")
+		if input, ok := spec["input"].(map[string]interface{}); ok {
+			step.Input = summarizeInput(input)
+		}
+
+		fn, found := byName[step.Function]
+		switch {
+		case denied:
+			step.Problem = "not allowed to read functions"
+		case !found:
+			step.Problem = "function is not installed"
+			missing[step.Function] = true
+		default:
+			step.Package = functionInfo(fn)
+			if step.Package.Installed != string(v12.ConditionTrue) || step.Package.Healthy != string(v12.ConditionTrue) {
+				step.Problem = "function is not healthy"
+				if step.Package.Condition != nil && step.Package.Condition.Message != "" {
+					step.Problem += ": " + step.Package.Condition.Message
+				}
+				unhealthy[step.Function] = true
+			}
+		}
+
+		res.Steps = append(res.Steps, step)
+	}
+
+	res.Missing = sortedKeys(missing)
+	res.Unhealthy = sortedKeys(unhealthy)
+	res.Healthy = !denied && len(res.Missing) == 0 && len(res.Unhealthy) == 0
+	return res
+}
+
+func functionInfo(fn *unstructured.Unstructured) *FunctionInfo {
+	res := &FunctionInfo{
+		Installed: conditionStatus(fn, cpv1.TypeInstalled),
+		Healthy:   conditionStatus(fn, cpv1.TypeHealthy),
+	}
+	res.Package, _, _ = unstructured.NestedString(fn.Object, "spec", "package")
+	res.Revision, _, _ = unstructured.NestedString(fn.Object, "status", "currentRevision")
+
+	// the identifier is resolved package reference, unlike spec that might have no tag
+	ident, _, _ := unstructured.NestedString(fn.Object, "status", "currentIdentifier")
+	if ident == "" {
+		ident = res.Package
+	}
+	res.Version = packageVersion(ident)
+
+	for _, typ := range []xpv1.ConditionType{cpv1.TypeInstalled, cpv1.TypeHealthy} {
+		if conditionStatus(fn, typ) != string(v12.ConditionTrue) {
+			res.Condition = conditionSummary(fn, typ)
+			break
+		}
+	}
+	return res
+}
+
+// packageVersion extracts tag or digest from OCI reference
+func packageVersion(ref string) string {
+	if idx := strings.LastIndex(ref, "@"); idx >= 0 {
+		return ref[idx+1:]
+	}
+
+	if idx := strings.LastIndex(ref, ":"); idx > strings.LastIndex(ref, "/") {
+		return ref[idx+1:]
+	}
+	return ""
+}
+
+// summarizeInput knows the inputs of popular functions, falling back to the list of fields for others
+func summarizeInput(input map[string]interface{}) *StepInput {
+	in := unstructured.Unstructured{Object: input}
+	res := &StepInput{APIVersion: in.GetAPIVersion(), Kind: in.GetKind()}
+
+	switch in.GroupVersionKind().Group {
+	case "pt.fn.crossplane.io": // function-patch-and-transform
+		resources, _, _ := unstructured.NestedSlice(input, "resources")
+		patchSets, _, _ := unstructured.NestedSlice(input, "patchSets")
+		patches := 0
+		for _, r := range resources {
+			if m, ok := r.(map[string]interface{}); ok {
+				p, _, _ := unstructured.NestedSlice(m, "patches")
+				patches += len(p)
+			}
+		}
+		res.Summary = fmt.Sprintf("%d resources, %d patches, %d patch sets", len(resources), patches, len(patchSets))
+	case "gotemplating.fn.crossplane.io": // function-go-templating
+		source, _, _ := unstructured.NestedString(input, "source")
+		switch source {
+		case "FileSystem":
+			dir, _, _ := unstructured.NestedString(input, "fileSystem", "dirPath")
+			res.Summary = "templates from directory " + dir
+		default:
+			tpl, _, _ := unstructured.NestedString(input, "inline", "template")
+			res.Summary = fmt.Sprintf("inline template, %d lines", countLines(tpl))
+		}
+	case "krm.kcl.dev": // function-kcl
+		source, _, _ := unstructured.NestedString(input, "spec", "source")
+		if strings.Contains(source, "://") && !strings.Contains(source, "\n") {
+			res.Summary = "KCL module " + source
+		} else {
+			res.Summary = fmt.Sprintf("inline KCL, %d lines", countLines(source))
+		}
+	default:
+		fields := []string{}
+		for key := range input {
+			if key != "apiVersion" && key != "kind" && key != "metadata" {
+				fields = append(fields, key)
+			}
+		}
+		sort.Strings(fields)
+		res.Summary = "fields: " + strings.Join(fields, ", ")
+	}
+
+	return res
+}
+
+func countLines(text string) int {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0
+	}
+	return strings.Count(text, "\n") + 1
+}
+
+func sortedKeys(m map[string]bool) []string {
+	res := make([]string, 0, len(m))
+	for key := range m {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
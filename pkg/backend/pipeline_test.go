@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func function(name string, pkg string, conds ...map[string]interface{}) unstructured.Unstructured {
+	fn := diagObj("Function", name, conds...)
+	fn.SetAPIVersion("pkg.crossplane.io/v1beta1")
+	fn.Object["spec"] = map[string]interface{}{"package": pkg}
+	status := fn.Object["status"].(map[string]interface{})
+	status["currentRevision"] = name + "-abc123"
+	status["currentIdentifier"] = pkg
+	return *fn
+}
+
+func TestBuildPipeline(t *testing.T) {
+	comp := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"mode": "Pipeline",
+			"pipeline": []interface{}{
+				map[string]interface{}{
+					"step":        "patch",
+					"functionRef": map[string]interface{}{"name": "function-patch-and-transform"},
+					"input": map[string]interface{}{
+						"apiVersion": "pt.fn.crossplane.io/v1beta1",
+						"kind":       "Resources",
+						"resources": []interface{}{
+							map[string]interface{}{"name": "a", "patches": []interface{}{"p1", "p2"}},
+							map[string]interface{}{"name": "b"},
+						},
+					},
+				},
+				map[string]interface{}{
+					"step":        "render",
+					"functionRef": map[string]interface{}{"name": "function-go-templating"},
+					"input": map[string]interface{}{
+						"apiVersion": "gotemplating.fn.crossplane.io/v1beta1",
+						"kind":       "GoTemplate",
+						"source":     "Inline",
+						"inline":     map[string]interface{}{"template": "a\nb\nc\n"},
+					},
+				},
+				map[string]interface{}{
+					"step":        "kcl",
+					"functionRef": map[string]interface{}{"name": "function-kcl"},
+				},
+				map[string]interface{}{
+					"step":        "ready",
+					"functionRef": map[string]interface{}{"name": "function-auto-ready"},
+				},
+			},
+		},
+	}}
+	comp.SetName("app")
+
+	functions := []unstructured.Unstructured{
+		function("function-patch-and-transform", "xpkg.upbound.io/crossplane-contrib/function-patch-and-transform:v0.2.1",
+			cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "True", "HealthyPackageRevision")),
+		function("function-go-templating", "xpkg.upbound.io/crossplane-contrib/function-go-templating@sha256:abc",
+			cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "False", "UnhealthyPackageRevision")),
+		function("function-auto-ready", "localhost:5000/function-auto-ready",
+			cond("Installed", "True", "ActivePackageRevision"), cond("Healthy", "True", "HealthyPackageRevision")),
+	}
+
+	res := buildPipeline(comp, functions, false)
+	assert.Equal(t, "app", res.Composition)
+	assert.Equal(t, "Pipeline", res.Mode)
+	assert.False(t, res.Healthy)
+	assert.Equal(t, []string{"function-kcl"}, res.Missing)
+	assert.Equal(t, []string{"function-go-templating"}, res.Unhealthy)
+
+	require.Len(t, res.Steps, 4)
+	assert.Equal(t, "v0.2.1", res.Steps[0].Package.Version)
+	assert.Equal(t, "function-patch-and-transform-abc123", res.Steps[0].Package.Revision)
+	assert.Equal(t, "2 resources, 2 patches, 0 patch sets", res.Steps[0].Input.Summary)
+	assert.Empty(t, res.Steps[0].Problem)
+
+	assert.Equal(t, "sha256:abc", res.Steps[1].Package.Version)
+	assert.Equal(t, "inline template, 3 lines", res.Steps[1].Input.Summary)
+	assert.Equal(t, "UnhealthyPackageRevision", res.Steps[1].Package.Condition.Reason)
+	assert.Contains(t, res.Steps[1].Problem, "not healthy")
+
+	assert.Nil(t, res.Steps[2].Package)
+	assert.Equal(t, "function is not installed", res.Steps[2].Problem)
+
+	assert.Empty(t, res.Steps[3].Package.Version, "port is not a tag")
+
+	denied := buildPipeline(comp, nil, true)
+	assert.False(t, denied.Healthy)
+	assert.Empty(t, denied.Missing)
+	assert.True(t, denied.Forbidden)
+}
+
+func TestBuildPipeline_ResourcesMode(t *testing.T) {
+	comp := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"resources": []interface{}{}},
+	}}
+
+	res := buildPipeline(comp, nil, false)
+	assert.Equal(t, "Resources", res.Mode)
+	assert.Empty(t, res.Steps)
+	assert.True(t, res.Healthy)
+}
+
+func TestSummarizeInput(t *testing.T) {
+	res := summarizeInput(map[string]interface{}{
+		"apiVersion": "krm.kcl.dev/v1alpha1",
+		"kind":       "KCLInput",
+		"spec":       map[string]interface{}{"source": "oci://ghcr.io/org/module"},
+	})
+	assert.Equal(t, "KCL module oci://ghcr.io/org/module", res.Summary)
+
+	res = summarizeInput(map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "Input",
+		"b":          1,
+		"a":          2,
+	})
+	assert.Equal(t, "fields: a, b", res.Summary)
+}
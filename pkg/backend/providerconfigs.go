@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	cpk8s "github.com/crossplane-contrib/provider-kubernetes/apis/v1alpha1"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// credential source categories, as providers name the same things differently
+const (
+	credsSecret           = "Secret"
+	credsInjectedIdentity = "InjectedIdentity"
+	credsWorkloadIdentity = "WorkloadIdentity"
+	credsUpbound          = "Upbound"
+	credsOther            = "Other"
+)
+
+var credentialSources = map[string]string{
+	"Secret":                        credsSecret,
+	"InjectedIdentity":              credsInjectedIdentity,
+	"IRSA":                          credsInjectedIdentity,
+	"PodIdentity":                   credsInjectedIdentity,
+	"SystemAssignedManagedIdentity": credsInjectedIdentity,
+	"UserAssignedManagedIdentity":   credsInjectedIdentity,
+	"WebIdentity":                   credsWorkloadIdentity,
+	"OIDCTokenFile":                 credsWorkloadIdentity,
+	"ImpersonateServiceAccount":     credsWorkloadIdentity,
+	"Upbound":                       credsUpbound,
+}
+
+// ProviderConfigInfo describes where ProviderConfig takes credentials from and whether it is used
+type ProviderConfigInfo struct {
+	Provider   string        `json:"provider"`
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Name       string        `json:"name"`
+	Source     string        `json:"source"`   // as set in the ProviderConfig
+	Category   string        `json:"category"` // one of Secret, InjectedIdentity, WorkloadIdentity, Upbound, Other
+	Secret     *SecretStatus `json:"secret,omitempty"`
+	Identity   *SecretStatus `json:"identity,omitempty"` // provider-kubernetes and provider-helm have separate identity credentials
+	Usages     int           `json:"usages"`
+	Problems   []string      `json:"problems"`
+}
+
+// ProviderConfigReport lists ProviderConfigs of all providers, with kinds that failed to load
+type ProviderConfigReport struct {
+	Items    []*ProviderConfigInfo `json:"items"`
+	Warnings []Warning             `json:"warnings,omitempty"`
+}
+
+func (c *Controller) GetProviderConfigsInspect(ec echo.Context) error {
+	allProvCRDs, err := c.LoadCRDs(ec)
+	if err != nil {
+		return err
+	}
+
+	type providerCRD struct {
+		provider string
+		crd      *v1.CustomResourceDefinition
+	}
+	crds := []providerCRD{}
+	for prov, provCRDs := range allProvCRDs {
+		if name := ec.QueryParam("provider"); name != "" && prov != name {
+			continue
+		}
+
+		for _, crd := range provCRDs {
+			// relying on the same naming convention as GetProviderConfigsInner
+			if kind := crd.Spec.Names.Kind; kind == cpk8s.ProviderConfigKind || kind == cpk8s.ProviderConfigUsageKind {
+				crds = append(crds, providerCRD{provider: prov, crd: crd})
+			}
+		}
+	}
+
+	ctx := c.reqCtx(ec)
+	lists := make([]*unstructured.UnstructuredList, len(crds))
+	errs := c.fanOut(ctx, len(crds), func(ctx context.Context, i int) (err error) {
+		gvk := schema.GroupVersionKind{
+			Group:   crds[i].crd.Spec.Group,
+			Version: crds[i].crd.Spec.Versions[0].Name,
+			Kind:    crds[i].crd.Spec.Names.Plural,
+		}
+		lists[i], err = c.CRDs.List(ctx, gvk)
+		return err
+	})
+
+	res := &ProviderConfigReport{Items: []*ProviderConfigInfo{}}
+	usages := map[schema.GroupKind]map[string]int{}
+	configs := []*unstructured.Unstructured{}
+	providers := []string{}
+	for i, err := range errs {
+		crd := crds[i].crd
+		if forbidden(err, crd.Name) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err() // nobody waits for the response anymore
+		}
+		if err != nil {
+			log.Warnf("Failed to list %s: %v", crd.Name, err)
+			res.Warnings = append(res.Warnings, Warning{Kind: crd.Spec.Names.Kind + "." + crd.Spec.Group, Message: err.Error()})
+			continue
+		}
+
+		if crd.Spec.Names.Kind == cpk8s.ProviderConfigKind {
+			for j := range lists[i].Items {
+				configs = append(configs, &lists[i].Items[j])
+				providers = append(providers, crds[i].provider)
+			}
+		} else {
+			usages[schema.GroupKind{Group: crd.Spec.Group, Kind: cpk8s.ProviderConfigKind}] = countUsages(lists[i].Items)
+		}
+	}
+
+	// credential Secrets are checked concurrently, as there might be a config per tenant or account
+	infos := make([]*ProviderConfigInfo, len(configs))
+	secrets := newSecretChecker(c.secrets, c.callTimeout)
+	c.fanOut(ctx, len(configs), func(ctx context.Context, i int) error {
+		infos[i] = inspectProviderConfig(ctx, secrets, configs[i])
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for i, info := range infos {
+		pc := configs[i]
+		info.Provider = providers[i]
+		counts, known := usages[pc.GroupVersionKind().GroupKind()]
+		info.Usages = counts[pc.GetName()]
+		if known && info.Usages == 0 {
+			info.Problems = append(info.Problems, "not used by any resource")
+		}
+		res.Items = append(res.Items, info)
+	}
+
+	sort.Slice(res.Items, func(i, j int) bool {
+		if res.Items[i].Provider != res.Items[j].Provider {
+			return res.Items[i].Provider < res.Items[j].Provider
+		}
+		return res.Items[i].Name < res.Items[j].Name
+	})
+
+	return ec.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func countUsages(items []unstructured.Unstructured) map[string]int {
+	res := map[string]int{}
+	for _, usage := range items {
+		name, _, _ := unstructured.NestedString(usage.Object, "providerConfigRef", "name")
+		res[name]++
+	}
+	return res
+}
+
+func inspectProviderConfig(ctx context.Context, secrets *secretChecker, pc *unstructured.Unstructured) *ProviderConfigInfo {
+	res := &ProviderConfigInfo{
+		APIVersion: pc.GetAPIVersion(),
+		Kind:       pc.GetKind(),
+		Name:       pc.GetName(),
+		Problems:   []string{},
+	}
+
+	res.Source, _, _ = unstructured.NestedString(pc.Object, "spec", "credentials", "source")
+	res.Secret = checkCredentialsSecret(ctx, secrets, pc, "spec", "credentials")
+	res.Category = credentialCategory(res.Source)
+	if res.Secret != nil && res.Secret.Problem != "" {
+		res.Problems = append(res.Problems, "credentials: "+res.Secret.Problem)
+	}
+
+	if _, found, _ := unstructured.NestedMap(pc.Object, "spec", "identity"); found {
+		res.Identity = checkCredentialsSecret(ctx, secrets, pc, "spec", "identity")
+		if res.Identity != nil && res.Identity.Problem != "" {
+			res.Problems = append(res.Problems, "identity: "+res.Identity.Problem)
+		}
+	}
+	return res
+}
+
+// checkCredentialsSecret follows the common `source: Secret` with `secretRef` structure, nil if the source is not Secret
+func checkCredentialsSecret(ctx context.Context, secrets *secretChecker, pc *unstructured.Unstructured, fields ...string) *SecretStatus {
+	source, _, _ := unstructured.NestedString(pc.Object, append(fields, "source")...)
+	if source != credsSecret {
+		return nil
+	}
+
+	ref, found, _ := unstructured.NestedMap(pc.Object, append(fields, "secretRef")...)
+	if !found {
+		return &SecretStatus{Problem: "secret reference is not set"}
+	}
+
+	namespace, _, _ := unstructured.NestedString(ref, "namespace")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	key, _, _ := unstructured.NestedString(ref, "key")
+	return secrets.check(ctx, namespace, name, key)
+}
+
+func credentialCategory(source string) string {
+	if category, found := credentialSources[source]; found {
+		return category
+	}
+	return credsOther
+}
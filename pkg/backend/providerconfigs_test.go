@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v12 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testSecrets() *secretChecker {
+	clientset := fake.NewSimpleClientset(&v12.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: "aws-creds"},
+		Data:       map[string][]byte{"credentials": []byte("s3cret"), "region": []byte("us-east-1")},
+	})
+	return newSecretChecker(clientset.CoreV1(), 0)
+}
+
+func providerConfig(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	pc := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	pc.SetAPIVersion("aws.upbound.io/v1beta1")
+	pc.SetKind("ProviderConfig")
+	pc.SetName(name)
+	return pc
+}
+
+func secretCreds(name string, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"source":    "Secret",
+		"secretRef": map[string]interface{}{"namespace": "crossplane-system", "name": name, "key": key},
+	}
+}
+
+func TestInspectProviderConfig(t *testing.T) {
+	secrets := testSecrets()
+	ctx := context.Background()
+
+	res := inspectProviderConfig(ctx, secrets, providerConfig("default", map[string]interface{}{"credentials": secretCreds("aws-creds", "credentials")}))
+	assert.Equal(t, "Secret", res.Category)
+	require.NotNil(t, res.Secret)
+	assert.True(t, res.Secret.Exists)
+	assert.Equal(t, []string{"credentials", "region"}, res.Secret.Keys)
+	assert.Empty(t, res.Problems)
+
+	res = inspectProviderConfig(ctx, secrets, providerConfig("wrong-key", map[string]interface{}{"credentials": secretCreds("aws-creds", "token")}))
+	assert.True(t, res.Secret.Exists)
+	assert.Equal(t, []string{"credentials: key token not found in secret"}, res.Problems)
+
+	res = inspectProviderConfig(ctx, secrets, providerConfig("missing", map[string]interface{}{"credentials": secretCreds("gone", "credentials")}))
+	assert.False(t, res.Secret.Exists)
+	assert.Equal(t, []string{"credentials: secret not found"}, res.Problems)
+
+	res = inspectProviderConfig(ctx, secrets, providerConfig("irsa", map[string]interface{}{"credentials": map[string]interface{}{"source": "IRSA"}}))
+	assert.Equal(t, "IRSA", res.Source)
+	assert.Equal(t, "InjectedIdentity", res.Category)
+	assert.Nil(t, res.Secret)
+	assert.Empty(t, res.Problems)
+
+	res = inspectProviderConfig(ctx, secrets, providerConfig("k8s", map[string]interface{}{
+		"credentials": map[string]interface{}{"source": "InjectedIdentity"},
+		"identity":    secretCreds("gcp-creds", "key.json"),
+	}))
+	require.NotNil(t, res.Identity)
+	assert.Equal(t, []string{"identity: secret not found"}, res.Problems)
+}
+
+func TestCountUsages(t *testing.T) {
+	usage := func(pc string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"providerConfigRef": map[string]interface{}{"name": pc},
+		}}
+	}
+
+	res := countUsages([]unstructured.Unstructured{usage("default"), usage("prod"), usage("default")})
+	assert.Equal(t, map[string]int{"default": 2, "prod": 1}, res)
+}
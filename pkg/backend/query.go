@@ -0,0 +1,269 @@
+package backend
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/labstack/echo/v4"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ListQuery is a set of server-side filtering, sorting and pagination options for list endpoints
+type ListQuery struct {
+	Limit          int
+	Selector       labels.Selector
+	Namespace      string
+	Kind           string
+	Provider       string
+	ProviderConfig string // name from `spec.providerConfigRef`
+	Ready          string
+	Synced         string
+	Name           string // substring match
+	Sort           string // field name, optionally prefixed with `-` for descending order
+
+	providers map[schema.GroupKind]string // required to filter by provider
+	after     *unstructured.Unstructured  // the last item of previous page, decoded from `continue` token
+}
+
+var sortFields = map[string]func(a, b *unstructured.Unstructured) bool{
+	"name": func(a, b *unstructured.Unstructured) bool {
+		return a.GetName() < b.GetName()
+	},
+	"namespace": func(a, b *unstructured.Unstructured) bool {
+		return a.GetNamespace() < b.GetNamespace()
+	},
+	"kind": func(a, b *unstructured.Unstructured) bool {
+		return a.GetKind() < b.GetKind()
+	},
+	"age": func(a, b *unstructured.Unstructured) bool { // the youngest goes first
+		ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+		return tb.Before(&ta)
+	},
+}
+
+func parseListQuery(ec echo.Context) (*ListQuery, error) {
+	q := ListQuery{
+		Selector:       labels.Everything(),
+		Namespace:      ec.QueryParam("namespace"),
+		Kind:           ec.QueryParam("kind"),
+		Provider:       ec.QueryParam("provider"),
+		ProviderConfig: ec.QueryParam("providerConfig"),
+		Ready:          ec.QueryParam("ready"),
+		Synced:         ec.QueryParam("synced"),
+		Name:           ec.QueryParam("name"),
+		Sort:           ec.QueryParam("sort"),
+	}
+
+	var err error
+	if limit := ec.QueryParam("limit"); limit != "" {
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil || q.Limit < 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid limit: "+limit)
+		}
+	}
+
+	if cont := ec.QueryParam("continue"); cont != "" {
+		var sortedBy string
+		q.after, sortedBy, err = decodeContinue(cont)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid continue token")
+		}
+		if sortedBy != q.Sort {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "continue token was issued for another sort order")
+		}
+	}
+
+	if sel := ec.QueryParam("labelSelector"); sel != "" {
+		q.Selector, err = labels.Parse(sel)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid label selector: "+err.Error())
+		}
+	}
+
+	for _, status := range []string{q.Ready, q.Synced} {
+		if status != "" && status != string(v12.ConditionTrue) && status != string(v12.ConditionFalse) && status != string(v12.ConditionUnknown) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "condition status filter must be one of True, False, Unknown")
+		}
+	}
+
+	if _, found := sortFields[strings.TrimPrefix(q.Sort, "-")]; q.Sort != "" && !found {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "unsupported sort field: "+q.Sort)
+	}
+
+	return &q, nil
+}
+
+// Apply filters, sorts and paginates the items, the result is a list with `continue` token in its metadata
+func (q *ListQuery) Apply(items []unstructured.Unstructured) *unstructured.UnstructuredList {
+	filtered := []unstructured.Unstructured{}
+	for i := range items {
+		if q.matches(&items[i]) {
+			filtered = append(filtered, items[i])
+		}
+	}
+
+	less := q.less()
+	sort.Slice(filtered, func(i, j int) bool {
+		return less(&filtered[i], &filtered[j])
+	})
+
+	// resuming after the last returned item, rather than by offset, is not affected by objects added or removed meanwhile
+	start := 0
+	if q.after != nil {
+		start = sort.Search(len(filtered), func(i int) bool {
+			return less(q.after, &filtered[i])
+		})
+	}
+
+	res := &unstructured.UnstructuredList{Object: map[string]interface{}{}, Items: []unstructured.Unstructured{}}
+	end := len(filtered)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+		res.SetContinue(encodeContinue(&filtered[end-1], q.Sort))
+		remaining := int64(len(filtered) - end)
+		res.SetRemainingItemCount(&remaining)
+	}
+
+	res.Items = filtered[start:end]
+	return res
+}
+
+// less orders by the sort field, then by identity of objects, so that every object has a stable position
+func (q *ListQuery) less() func(a, b *unstructured.Unstructured) bool {
+	byField := sortFields[strings.TrimPrefix(q.Sort, "-")]
+	desc := strings.HasPrefix(q.Sort, "-")
+	return func(a, b *unstructured.Unstructured) bool {
+		if byField != nil {
+			x, y := a, b
+			if desc {
+				x, y = b, a
+			}
+			if byField(x, y) {
+				return true
+			}
+			if byField(y, x) {
+				return false
+			}
+		}
+
+		if a.GetAPIVersion() != b.GetAPIVersion() {
+			return a.GetAPIVersion() < b.GetAPIVersion()
+		}
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	}
+}
+
+func (q *ListQuery) matches(item *unstructured.Unstructured) bool {
+	if q.Namespace != "" && item.GetNamespace() != q.Namespace {
+		return false
+	}
+
+	if q.Kind != "" && !strings.EqualFold(item.GetKind(), q.Kind) {
+		return false
+	}
+
+	if q.Name != "" && !strings.Contains(strings.ToLower(item.GetName()), strings.ToLower(q.Name)) {
+		return false
+	}
+
+	if !q.Selector.Matches(labels.Set(item.GetLabels())) {
+		return false
+	}
+
+	if q.Provider != "" && q.providers[item.GroupVersionKind().GroupKind()] != q.Provider {
+		return false
+	}
+
+	if q.ProviderConfig != "" {
+		if name, _, _ := unstructured.NestedString(item.Object, "spec", "providerConfigRef", "name"); name != q.ProviderConfig {
+			return false
+		}
+	}
+
+	if q.Ready != "" && conditionStatus(item, xpv1.TypeReady) != q.Ready {
+		return false
+	}
+
+	if q.Synced != "" && conditionStatus(item, xpv1.TypeSynced) != q.Synced {
+		return false
+	}
+
+	return true
+}
+
+// conditionStatus reads the status of condition without converting the whole object, missing condition is Unknown
+func conditionStatus(item *unstructured.Unstructured, typ xpv1.ConditionType) string {
+	conds, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, cond := range conds {
+		c, ok := cond.(map[string]interface{})
+		if ok && c["type"] == string(typ) {
+			if status, ok := c["status"].(string); ok {
+				return status
+			}
+		}
+	}
+	return string(v12.ConditionUnknown)
+}
+
+// continueToken holds the fields of the last returned item that define its position in the sorted list
+type continueToken struct {
+	Sort       string `json:"sort,omitempty"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Created    string `json:"created,omitempty"`
+}
+
+func encodeContinue(last *unstructured.Unstructured, sortedBy string) string {
+	created, _, _ := unstructured.NestedString(last.Object, "metadata", "creationTimestamp")
+	data, _ := json.Marshal(continueToken{
+		Sort:       sortedBy,
+		APIVersion: last.GetAPIVersion(),
+		Kind:       last.GetKind(),
+		Namespace:  last.GetNamespace(),
+		Name:       last.GetName(),
+		Created:    created,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinue(token string) (*unstructured.Unstructured, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cont := continueToken{}
+	if err := json.Unmarshal(data, &cont); err != nil {
+		return nil, "", err
+	}
+	if cont.Name == "" {
+		return nil, "", fmt.Errorf("no item in continue token: %s", data)
+	}
+
+	last := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	last.SetAPIVersion(cont.APIVersion)
+	last.SetKind(cont.Kind)
+	last.SetNamespace(cont.Namespace)
+	last.SetName(cont.Name)
+	if cont.Created != "" {
+		_ = unstructured.SetNestedField(last.Object, cont.Created, "metadata", "creationTimestamp")
+	}
+	return last, cont.Sort, nil
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testObject(kind, namespace, name string, age time.Duration, ready string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("example.org/v1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	obj.SetLabels(map[string]string{"team": namespace})
+	if ready != "" {
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": ready},
+			},
+		}
+	}
+	return obj
+}
+
+func testQuery(t *testing.T, query string) *ListQuery {
+	req := httptest.NewRequest(http.MethodGet, "/api/managed?"+query, nil)
+	ec := echo.New().NewContext(req, httptest.NewRecorder())
+	q, err := parseListQuery(ec)
+	require.NoError(t, err)
+	return q
+}
+
+func names(list *unstructured.UnstructuredList) []string {
+	res := []string{}
+	for _, item := range list.Items {
+		res = append(res, item.GetName())
+	}
+	return res
+}
+
+func TestListQuery_Apply(t *testing.T) {
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "dev", "bucket-a", 3*time.Hour, "True"),
+		testObject("Bucket", "prod", "bucket-b", 1*time.Hour, "False"),
+		testObject("Queue", "dev", "queue-a", 2*time.Hour, ""),
+	}
+
+	tests := []struct {
+		query string
+		out   []string
+	}{
+		{"", []string{"bucket-a", "bucket-b", "queue-a"}},
+		{"namespace=dev", []string{"bucket-a", "queue-a"}},
+		{"kind=bucket", []string{"bucket-a", "bucket-b"}},
+		{"name=QUEUE", []string{"queue-a"}},
+		{"labelSelector=team%3Dprod", []string{"bucket-b"}},
+		{"ready=True", []string{"bucket-a"}},
+		{"ready=Unknown", []string{"queue-a"}},
+		{"sort=-name", []string{"queue-a", "bucket-b", "bucket-a"}},
+		{"sort=age", []string{"bucket-b", "queue-a", "bucket-a"}},
+		{"sort=-age&limit=2", []string{"bucket-a", "queue-a"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			assert.Equal(t, test.out, names(testQuery(t, test.query).Apply(items)))
+		})
+	}
+}
+
+func TestListQuery_Provider(t *testing.T) {
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "", "bucket-a", 0, ""),
+		testObject("Queue", "", "queue-a", 0, ""),
+	}
+
+	q := testQuery(t, "provider=provider-aws")
+	q.providers = map[schema.GroupKind]string{{Group: "example.org", Kind: "Queue"}: "provider-aws"}
+	assert.Equal(t, []string{"queue-a"}, names(q.Apply(items)))
+}
+
+func TestListQuery_ProviderConfig(t *testing.T) {
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "", "bucket-a", 0, ""),
+		testObject("Bucket", "", "bucket-b", 0, ""),
+		testObject("Queue", "", "queue-a", 0, ""),
+	}
+	items[0].Object["spec"] = map[string]interface{}{"providerConfigRef": map[string]interface{}{"name": "default"}}
+	items[1].Object["spec"] = map[string]interface{}{"providerConfigRef": map[string]interface{}{"name": "prod"}}
+	items[2].Object["spec"] = map[string]interface{}{"providerConfigRef": map[string]interface{}{"name": "prod"}}
+
+	q := testQuery(t, "provider=provider-aws&providerConfig=prod")
+	q.providers = map[schema.GroupKind]string{{Group: "example.org", Kind: "Bucket"}: "provider-aws"}
+	assert.Equal(t, []string{"bucket-b"}, names(q.Apply(items)))
+}
+
+func TestListQuery_Pagination(t *testing.T) {
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "", "a", 0, ""),
+		testObject("Bucket", "", "b", 0, ""),
+		testObject("Bucket", "", "c", 0, ""),
+	}
+
+	page := testQuery(t, "limit=2").Apply(items)
+	assert.Equal(t, []string{"a", "b"}, names(page))
+	assert.Equal(t, int64(1), *page.GetRemainingItemCount())
+
+	data, err := json.Marshal(page)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"continue":"`+page.GetContinue()+`"`)
+	assert.Contains(t, string(data), `"items":[`)
+
+	page = testQuery(t, "limit=2&continue="+page.GetContinue()).Apply(items)
+	assert.Equal(t, []string{"c"}, names(page))
+	assert.Empty(t, page.GetContinue())
+}
+
+func TestListQuery_PaginationChanges(t *testing.T) {
+	items := []unstructured.Unstructured{
+		testObject("Bucket", "", "b", time.Hour, ""),
+		testObject("Bucket", "", "c", 2*time.Hour, ""),
+		testObject("Bucket", "", "d", 3*time.Hour, ""),
+		testObject("Bucket", "", "e", 4*time.Hour, ""),
+	}
+
+	page := testQuery(t, "sort=-age&limit=2").Apply(items)
+	assert.Equal(t, []string{"e", "d"}, names(page))
+
+	// the last returned item is gone and a new one appears before the window
+	items = append([]unstructured.Unstructured{testObject("Bucket", "", "a", 5*time.Hour, "")}, items[:2]...)
+	page = testQuery(t, "sort=-age&limit=2&continue="+page.GetContinue()).Apply(items)
+	assert.Equal(t, []string{"c", "b"}, names(page), "nothing is skipped or repeated")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/managed?sort=name&continue="+testQuery(t, "limit=1").Apply(items).GetContinue(), nil)
+	_, err := parseListQuery(echo.New().NewContext(req, httptest.NewRecorder()))
+	assert.Error(t, err, "the token is for another order")
+}
+
+func TestParseListQuery_Invalid(t *testing.T) {
+	for _, query := range []string{"limit=-1", "limit=x", "continue=!!!", "ready=Yes", "sort=color", "labelSelector=a%20b"} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/managed?"+query, nil)
+			ec := echo.New().NewContext(req, httptest.NewRecorder())
+			_, err := parseListQuery(ec)
+			assert.Error(t, err)
+		})
+	}
+}
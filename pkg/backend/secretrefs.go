@@ -0,0 +1,298 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	storeTypeKubernetes = "Kubernetes"
+	defaultStoreConfig  = "default"
+)
+
+var storeConfigGVK = schema.GroupVersionKind{Group: "secrets.crossplane.io", Version: "v1alpha1", Kind: "StoreConfig"}
+
+// SecretReport lists secret references of claims, XRs, MRs and ProviderConfigs, with problems found
+type SecretReport struct {
+	Checked   int             `json:"checked"`
+	Problems  int             `json:"problems"`
+	Objects   []ObjectSecrets `json:"objects"`
+	Forbidden []string        `json:"forbidden,omitempty"` // kinds hidden by RBAC, their secrets are not checked
+	Warnings  []Warning       `json:"warnings,omitempty"`  // kinds that failed to load, their secrets are not checked
+}
+
+type ObjectSecrets struct {
+	ObjectRef
+	Class   string            `json:"class"`
+	Secrets []SecretReference `json:"secrets"`
+}
+
+type SecretReference struct {
+	Field string `json:"field"` // path of the reference in the object, like `spec.writeConnectionSecretToRef`
+	SecretStatus
+	Skipped string `json:"skipped,omitempty"` // the reason why the secret could not be checked
+}
+
+// secretRefCollector finds secret references in objects and checks them, it is safe for concurrent use
+type secretRefCollector struct {
+	secrets      *secretChecker
+	connKeys     map[schema.GroupKind][]string // from XRDs, for both XR and claim kinds
+	storeConfigs func(ctx context.Context, name string) (*unstructured.Unstructured, error)
+	storesMx     sync.Mutex // only guards the map, each store is loaded once without holding it
+	stores       map[string]*storeConfig
+}
+
+type storeConfig struct {
+	once  sync.Once
+	store *unstructured.Unstructured
+	err   error
+}
+
+type classified struct {
+	obj   *unstructured.Unstructured
+	class string
+}
+
+func (c *Controller) GetSecretDiagnostics(ec echo.Context) error {
+	xrds, err := c.cachedListXRDs(ec)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.reqCtx(ec)
+	collector := &secretRefCollector{
+		secrets:  newSecretChecker(c.secrets, c.callTimeout),
+		connKeys: connectionSecretKeys(xrds),
+		storeConfigs: func(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+			res := &unstructured.Unstructured{}
+			ref := v12.ObjectReference{Name: name}
+			ref.SetGroupVersionKind(storeConfigGVK)
+			return res, c.CRDs.Get(ctx, res, &ref)
+		},
+		stores: map[string]*storeConfig{},
+	}
+
+	report := &SecretReport{Objects: []ObjectSecrets{}}
+	objects := []classified{}
+	for _, class := range []tracker.Class{tracker.ClassClaim, tracker.ClassComposite, tracker.ClassManaged} {
+		items, forbiddenKinds := c.listVisible(ec, class)
+		report.Forbidden = append(report.Forbidden, forbiddenKinds...)
+		for i := range items {
+			objects = append(objects, classified{obj: &items[i], class: string(class)})
+		}
+	}
+
+	configs, err := c.GetProviderConfigsInner(ec, "")
+	if err != nil {
+		return err
+	}
+	report.Warnings, _ = configs.Object["warnings"].([]Warning)
+	for i := range configs.Items {
+		objects = append(objects, classified{obj: &configs.Items[i], class: "providerconfig"})
+	}
+
+	// each object might reference several Secrets, checking them one by one is too slow for big clusters
+	results := make([]*ObjectSecrets, len(objects))
+	c.fanOut(ctx, len(objects), func(ctx context.Context, i int) error {
+		results[i] = collector.collect(ctx, objects[i].obj, objects[i].class)
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, res := range results {
+		report.add(res)
+	}
+
+	sort.Strings(report.Forbidden)
+	if ec.QueryParam("problems") != "" {
+		report.Objects = onlyProblems(report.Objects)
+	}
+
+	return ec.JSONPretty(http.StatusOK, report, "  ")
+}
+
+func (r *SecretReport) add(obj *ObjectSecrets) {
+	if len(obj.Secrets) == 0 {
+		return
+	}
+
+	r.Objects = append(r.Objects, *obj)
+	for _, secret := range obj.Secrets {
+		r.Checked++
+		if secret.Problem != "" {
+			r.Problems++
+		}
+	}
+}
+
+func onlyProblems(objects []ObjectSecrets) []ObjectSecrets {
+	res := []ObjectSecrets{}
+	for _, obj := range objects {
+		refs := []SecretReference{}
+		for _, ref := range obj.Secrets {
+			if ref.Problem != "" {
+				refs = append(refs, ref)
+			}
+		}
+
+		if len(refs) > 0 {
+			obj.Secrets = refs
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
+func connectionSecretKeys(xrds *cpext.CompositeResourceDefinitionList) map[schema.GroupKind][]string {
+	res := map[schema.GroupKind][]string{}
+	for _, xrd := range xrds.Items {
+		keys := xrd.GetConnectionSecretKeys()
+		if len(keys) == 0 {
+			continue
+		}
+
+		res[schema.GroupKind{Group: xrd.Spec.Group, Kind: xrd.Spec.Names.Kind}] = keys
+		if xrd.Spec.ClaimNames != nil {
+			res[schema.GroupKind{Group: xrd.Spec.Group, Kind: xrd.Spec.ClaimNames.Kind}] = keys
+		}
+	}
+	return res
+}
+
+func (s *secretRefCollector) collect(ctx context.Context, obj *unstructured.Unstructured, class string) *ObjectSecrets {
+	res := &ObjectSecrets{
+		ObjectRef: ObjectRef{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		},
+		Class:   class,
+		Secrets: []SecretReference{},
+	}
+
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !found {
+		return res
+	}
+
+	if ref, found := spec["writeConnectionSecretToRef"].(map[string]interface{}); found {
+		name, _, _ := unstructured.NestedString(ref, "name")
+		namespace := obj.GetNamespace() // claims have it implicitly
+		if ns, _, _ := unstructured.NestedString(ref, "namespace"); ns != "" {
+			namespace = ns
+		}
+
+		keys := s.connKeys[obj.GroupVersionKind().GroupKind()]
+		res.Secrets = append(res.Secrets, SecretReference{
+			Field:        "spec.writeConnectionSecretToRef",
+			SecretStatus: *s.secrets.check(ctx, namespace, name, keys...),
+		})
+	}
+
+	if ref, found := spec["publishConnectionDetailsTo"].(map[string]interface{}); found {
+		res.Secrets = append(res.Secrets, s.checkPublished(ctx, obj, ref))
+	}
+
+	walkSecretRefs(spec, "spec", func(field string, ref map[string]interface{}) {
+		name, _, _ := unstructured.NestedString(ref, "name")
+		key, _, _ := unstructured.NestedString(ref, "key")
+		namespace, _, _ := unstructured.NestedString(ref, "namespace")
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+
+		res.Secrets = append(res.Secrets, SecretReference{
+			Field:        field,
+			SecretStatus: *s.secrets.check(ctx, namespace, name, key),
+		})
+	})
+
+	return res
+}
+
+// checkPublished resolves the secret published to Kubernetes secret store, other stores can't be checked
+func (s *secretRefCollector) checkPublished(ctx context.Context, obj *unstructured.Unstructured, ref map[string]interface{}) SecretReference {
+	res := SecretReference{Field: "spec.publishConnectionDetailsTo"}
+	res.Name, _, _ = unstructured.NestedString(ref, "name")
+
+	storeName, _, _ := unstructured.NestedString(ref, "configRef", "name")
+	if storeName == "" {
+		storeName = defaultStoreConfig
+	}
+
+	s.storesMx.Lock()
+	cached, found := s.stores[storeName]
+	if !found {
+		cached = &storeConfig{}
+		s.stores[storeName] = cached
+	}
+	s.storesMx.Unlock()
+
+	cached.once.Do(func() {
+		cached.store, cached.err = s.storeConfigs(ctx, storeName)
+	})
+	if cached.err != nil {
+		res.Problem = "failed to get StoreConfig " + storeName + ": " + cached.err.Error()
+		return res
+	}
+	store := cached.store
+
+	if typ, _, _ := unstructured.NestedString(store.Object, "spec", "type"); typ != "" && typ != storeTypeKubernetes {
+		res.Skipped = fmt.Sprintf("published to %s secret store", typ)
+		return res
+	}
+
+	res.Namespace = obj.GetNamespace()
+	if res.Namespace == "" {
+		res.Namespace, _, _ = unstructured.NestedString(store.Object, "spec", "defaultScope")
+	}
+
+	res.SecretStatus = *s.secrets.check(ctx, res.Namespace, res.Name)
+	return res
+}
+
+// walkSecretRefs calls back for each `secretRef` or `*SecretRef` field holding a secret reference,
+// ignoring the ones next to credentials `source` other than Secret
+func walkSecretRefs(value interface{}, path string, callback func(field string, ref map[string]interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // for stable order of results
+
+		source, hasSource := v["source"].(string)
+
+		for _, key := range keys {
+			field := path + "." + key
+			ref, isMap := v[key].(map[string]interface{})
+			if isMap && (key == "secretRef" || strings.HasSuffix(key, "SecretRef")) {
+				if _, hasName := ref["name"]; hasName {
+					if !hasSource || source == credsSecret {
+						callback(field, ref)
+					}
+					continue
+				}
+			}
+			walkSecretRefs(v[key], field, callback)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkSecretRefs(item, fmt.Sprintf("%s[%d]", path, i), callback)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v12 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testCollector(stores map[string]string) *secretRefCollector {
+	return &secretRefCollector{
+		secrets:  testSecrets(),
+		connKeys: map[schema.GroupKind][]string{{Group: "example.org", Kind: "Bucket"}: {"credentials", "endpoint"}},
+		storeConfigs: func(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+			typ, found := stores[name]
+			if !found {
+				return nil, errors.New("not found")
+			}
+			store := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			_ = unstructured.SetNestedField(store.Object, typ, "spec", "type")
+			_ = unstructured.SetNestedField(store.Object, "crossplane-system", "spec", "defaultScope")
+			return store, nil
+		},
+		stores: map[string]*storeConfig{},
+	}
+}
+
+func TestSecretRefCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+	collector := testCollector(map[string]string{"default": "Kubernetes", "vault": "Vault"})
+
+	claim := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{
+		"writeConnectionSecretToRef": map[string]interface{}{"name": "aws-creds"},
+	}}}
+	claim.SetAPIVersion("example.org/v1")
+	claim.SetKind("Bucket")
+	claim.SetNamespace("crossplane-system")
+	claim.SetName("bucket")
+
+	res := collector.collect(ctx, claim, "claim")
+	require.Len(t, res.Secrets, 1)
+	assert.Equal(t, "crossplane-system", res.Secrets[0].Namespace, "claim namespace is implied")
+	assert.Equal(t, "key endpoint not found in secret", res.Secrets[0].Problem)
+
+	managed := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{
+		"forProvider": map[string]interface{}{
+			"passwordSecretRef": map[string]interface{}{"namespace": "crossplane-system", "name": "aws-creds", "key": "region"},
+			"users": []interface{}{
+				map[string]interface{}{"tokenSecretRef": map[string]interface{}{"namespace": "default", "name": "missing"}},
+			},
+		},
+		"publishConnectionDetailsTo": map[string]interface{}{"name": "db", "configRef": map[string]interface{}{"name": "vault"}},
+	}}}
+	managed.SetAPIVersion("rds.aws.upbound.io/v1beta1")
+	managed.SetKind("Instance")
+	managed.SetName("db")
+
+	res = collector.collect(ctx, managed, "managed")
+	require.Len(t, res.Secrets, 3)
+	assert.Equal(t, "spec.publishConnectionDetailsTo", res.Secrets[0].Field)
+	assert.Equal(t, "published to Vault secret store", res.Secrets[0].Skipped)
+	assert.Empty(t, res.Secrets[0].Problem)
+	assert.Equal(t, "spec.forProvider.passwordSecretRef", res.Secrets[1].Field)
+	assert.Empty(t, res.Secrets[1].Problem)
+	assert.Equal(t, "spec.forProvider.users[0].tokenSecretRef", res.Secrets[2].Field)
+	assert.Equal(t, "secret not found", res.Secrets[2].Problem)
+
+	managed.Object["spec"] = map[string]interface{}{"publishConnectionDetailsTo": map[string]interface{}{"name": "aws-creds"}}
+	res = collector.collect(ctx, managed, "managed")
+	require.Len(t, res.Secrets, 1)
+	assert.Equal(t, "crossplane-system", res.Secrets[0].Namespace, "default scope of the store")
+	assert.True(t, res.Secrets[0].Exists)
+
+	pc := providerConfig("irsa", map[string]interface{}{"credentials": map[string]interface{}{
+		"source":    "IRSA",
+		"secretRef": map[string]interface{}{"namespace": "crossplane-system", "name": "leftover", "key": "credentials"},
+	}})
+	res = collector.collect(ctx, pc, "providerconfig")
+	assert.Empty(t, res.Secrets, "secret is not used with other sources")
+}
+
+func TestSecretReport(t *testing.T) {
+	report := &SecretReport{Objects: []ObjectSecrets{}}
+	report.add(&ObjectSecrets{ObjectRef: ObjectRef{Name: "empty"}, Secrets: []SecretReference{}})
+	report.add(&ObjectSecrets{ObjectRef: ObjectRef{Name: "a"}, Secrets: []SecretReference{
+		{Field: "spec.writeConnectionSecretToRef"},
+		{Field: "spec.forProvider.passwordSecretRef", SecretStatus: SecretStatus{Problem: "secret not found"}},
+	}})
+	report.add(&ObjectSecrets{ObjectRef: ObjectRef{Name: "b"}, Secrets: []SecretReference{{Field: "spec.writeConnectionSecretToRef"}}})
+
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 1, report.Problems)
+	require.Len(t, report.Objects, 2)
+
+	problems := onlyProblems(report.Objects)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "a", problems[0].Name)
+	assert.Len(t, problems[0].Secrets, 1)
+	assert.Len(t, report.Objects[0].Secrets, 2, "original is kept")
+}
+
+func TestSecretChecker_Lookup(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&v12.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: "aws-creds"},
+		Data:       map[string][]byte{"credentials": []byte("s3cret")},
+	})
+	gets := atomic.Int32{}
+	clientset.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets.Add(1)
+		return false, nil, nil
+	})
+	checker := newSecretChecker(clientset.CoreV1(), time.Minute)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := checker.check(context.Background(), "crossplane-system", "aws-creds", "credentials")
+			assert.Empty(t, status.Problem)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), gets.Load(), "concurrent checks of the same secret share one call")
+}
+
+func TestSecretRefCollector_SlowStore(t *testing.T) {
+	collector := testCollector(map[string]string{"default": "Kubernetes"})
+	getDefault := collector.storeConfigs
+	release := make(chan struct{})
+	collector.storeConfigs = func(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+		if name == "slow" {
+			<-release
+			return nil, errors.New("timeout")
+		}
+		return getDefault(ctx, name)
+	}
+
+	published := func(store string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{
+			"publishConnectionDetailsTo": map[string]interface{}{"name": "aws-creds", "configRef": map[string]interface{}{"name": store}},
+		}}}
+		obj.SetNamespace("crossplane-system")
+		return obj
+	}
+
+	slow := make(chan *ObjectSecrets)
+	go func() { slow <- collector.collect(context.Background(), published("slow"), "managed") }()
+
+	done := make(chan *ObjectSecrets)
+	go func() { done <- collector.collect(context.Background(), published("default"), "managed") }()
+	select {
+	case res := <-done:
+		assert.Empty(t, res.Secrets[0].Problem)
+	case <-time.After(5 * time.Second):
+		t.Fatal("other stores wait for the slow one")
+	}
+
+	close(release)
+	assert.Equal(t, "failed to get StoreConfig slow: timeout", (<-slow).Secrets[0].Problem)
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// SecretStatus tells whether referenced Secret and its key exist, never revealing the values
+type SecretStatus struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Key       string   `json:"key,omitempty"`
+	Exists    bool     `json:"exists"`
+	Keys      []string `json:"keys,omitempty"` // names of keys present in Secret
+	Problem   string   `json:"problem,omitempty"`
+}
+
+// secretChecker looks up Secrets once per request, keeping only the key names. It is safe for concurrent use.
+type secretChecker struct {
+	client  corev1client.SecretsGetter
+	timeout time.Duration // per Secret, none if zero
+	mx      sync.Mutex
+	cache   map[string]*secretKeys
+}
+
+type secretKeys struct {
+	once sync.Once
+	keys []string
+	err  error
+}
+
+func newSecretChecker(client corev1client.SecretsGetter, timeout time.Duration) *secretChecker {
+	return &secretChecker{client: client, timeout: timeout, cache: map[string]*secretKeys{}}
+}
+
+// check returns status with a problem if Secret is missing, or lacks any of the keys
+func (s *secretChecker) check(ctx context.Context, namespace string, name string, keys ...string) *SecretStatus {
+	res := &SecretStatus{Namespace: namespace, Name: name}
+	if len(keys) == 1 {
+		res.Key = keys[0]
+	}
+
+	if name == "" || namespace == "" {
+		res.Problem = "secret reference lacks name or namespace"
+		return res
+	}
+
+	found := s.lookup(ctx, namespace, name)
+	switch {
+	case k8sErrors.IsNotFound(found.err):
+		res.Problem = "secret not found"
+		return res
+	case k8sErrors.IsForbidden(found.err):
+		res.Problem = "not allowed to read the secret"
+		return res
+	case found.err != nil:
+		res.Problem = "failed to get the secret: " + found.err.Error()
+		return res
+	}
+
+	res.Exists = true
+	res.Keys = found.keys
+	for _, key := range keys {
+		if key != "" && !slices.Contains(found.keys, key) {
+			res.Problem = "key " + key + " not found in secret"
+			break
+		}
+	}
+	return res
+}
+
+// lookup fetches each Secret once, concurrent callers of the same one wait for the first
+func (s *secretChecker) lookup(ctx context.Context, namespace string, name string) *secretKeys {
+	cacheKey := namespace + "/" + name
+	s.mx.Lock()
+	res, found := s.cache[cacheKey]
+	if !found {
+		res = &secretKeys{}
+		s.cache[cacheKey] = res
+	}
+	s.mx.Unlock()
+
+	res.once.Do(func() {
+		ctx, cancel := withTimeout(ctx, s.timeout)
+		defer cancel()
+
+		res.keys = []string{}
+		secret, err := s.client.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			res.err = err
+			return
+		}
+		for key := range secret.Data {
+			res.keys = append(res.keys, key)
+		}
+		sort.Strings(res.keys)
+	})
+	return res
+}
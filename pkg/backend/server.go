@@ -8,58 +8,114 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-version"
+	"github.com/komodorio/komoplane/pkg/backend/auth"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 type ControlChan = chan struct{}
 
 type Server struct {
-	Version    string
-	Namespace  string
-	Address    string
-	Debug      bool
-	NoTracking bool
+	Version       string
+	Namespace     string
+	Address       string
+	Debug         bool
+	NoTracking    bool
+	EnableActions bool
+	Contexts      []string
+	AllContexts   bool
+	Auth          auth.Config
+	Impersonate   bool
+	TLSCert       string
+	TLSKey        string
+	TLSClientCA   string // to require client certificates
 }
 
 func (s *Server) StartServer(ctx context.Context) (string, ControlChan, error) {
-	cfg, err := getK8sConfig()
-	if err != nil {
-		return "", nil, err
+	if s.Impersonate && s.Auth.Mode == auth.ModeToken {
+		// the static token has no identity, so it would impersonate a Kubernetes user named "token"
+		return "", nil, errors.New("impersonation requires an auth mode that identifies users, it can't be used with token auth")
 	}
 
-	data, err := NewController(ctx, cfg, s.Namespace, s.Version)
+	configs, defaultName, err := getK8sConfigs(s.Contexts, s.AllContexts)
 	if err != nil {
 		return "", nil, err
 	}
 
 	isDevModeWithAnalytics := os.Getenv("HD_DEV_ANALYTICS") == "true"
-	data.StatusInfo.CurVer = os.Getenv("KP_VERSION")
-	data.StatusInfo.LatestVer = os.Getenv("KP_VERSION")
+	status := StatusInfo{
+		CurVer:    os.Getenv("KP_VERSION"),
+		LatestVer: os.Getenv("KP_VERSION"),
+	}
 	if s.Debug {
-		data.StatusInfo.LatestVer += ".1-dev" // for testing the notifications
+		status.LatestVer += ".1-dev" // for testing the notifications
 	}
-	data.StatusInfo.Analytics = (!s.NoTracking && s.Version != "0.0.0") || isDevModeWithAnalytics
+	status.Analytics = (!s.NoTracking && s.Version != "0.0.0") || isDevModeWithAnalytics
+	status.ActionsEnabled = s.EnableActions
+	status.Impersonate = s.Impersonate
 
-	if data.StatusInfo.Analytics {
+	if status.Analytics {
 		log.Infof("User analytics is collected to improve the quality, disable it with --no-analytics")
 	}
 
-	go checkUpgrade(&data.StatusInfo)
+	if status.ActionsEnabled {
+		log.Warnf("Actions that change resources are enabled, komoplane is not read-only")
+	}
+
+	clusters := NewClusters(ctx, configs, defaultName, s.Namespace, &status)
+	_, err = clusters.Get(defaultName) // others are connected on first request
+	if err != nil {
+		return "", nil, err
+	}
+
+	go checkUpgrade(&status)
 
-	api := NewRouter(data, s.Debug)
-	done := s.startBackgroundServer(api, ctx)
+	authn, err := auth.New(ctx, s.Auth)
+	if err != nil {
+		return "", nil, err
+	}
 
+	if authn == nil {
+		log.Warnf("Authentication is off, anyone who can reach komoplane can see the resources, use --auth to enable it")
+		if s.Impersonate {
+			log.Warnf("Impersonation has no effect without authentication")
+		}
+	} else {
+		log.Infof("Authentication mode: %s", s.Auth.Mode)
+	}
+
+	api := NewRouter(clusters, authn, s.Auth.MetricsToken, s.Debug)
+
+	var certs *certReloader
+	if s.TLSCert != "" || s.TLSKey != "" {
+		certs, err = newCertReloader(s.TLSCert, s.TLSKey, s.TLSClientCA)
+		if err != nil {
+			return "", nil, err
+		}
+	} else if s.TLSClientCA != "" {
+		return "", nil, errors.New("client CA requires TLS certificate and key to be set")
+	}
+
+	done := s.startBackgroundServer(api, ctx, certs)
+
+	if certs != nil {
+		return "https://" + s.Address, done, nil
+	}
 	return "http://" + s.Address, done, nil
 }
 
-func (s *Server) startBackgroundServer(routes *echo.Echo, ctx context.Context) ControlChan {
+func (s *Server) startBackgroundServer(routes *echo.Echo, ctx context.Context, certs *certReloader) ControlChan {
 	done := make(ControlChan)
 	server := &http.Server{
 		Addr:    s.Address,
 		Handler: routes,
 	}
 
+	if certs != nil {
+		server.TLSConfig = certs.TLSConfig()
+	}
+
 	go func() {
 		<-ctx.Done()
 		err := server.Shutdown(context.Background())
@@ -70,7 +126,12 @@ func (s *Server) startBackgroundServer(routes *echo.Echo, ctx context.Context) C
 	}()
 
 	go func() {
-		err := server.ListenAndServe()
+		var err error
+		if certs != nil {
+			err = server.ListenAndServeTLS("", "") // certificates come from TLSConfig
+		} else {
+			err = server.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			log.Warnf("Looks like port is busy for %s", s.Address)
 			panic(err)
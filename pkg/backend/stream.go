@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/komodorio/komoplane/pkg/backend/auth"
+	"github.com/komodorio/komoplane/pkg/backend/tracker"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// GetStream sends changes of tracked objects as Server-Sent Events, optionally filtered by kind, namespace and name
+func (c *Controller) GetStream(ec echo.Context) error {
+	filter := streamFilter{
+		kind:      ec.QueryParam("kind"),
+		namespace: ec.QueryParam("namespace"),
+		name:      ec.QueryParam("name"),
+	}
+
+	events, unsubscribe := c.Tracker.Subscribe(100)
+	defer unsubscribe()
+
+	resp := ec.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+	resp.Header().Set(echo.HeaderConnection, "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	resp.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	done := ec.Request().Context().Done()
+	for {
+		select {
+		case <-done:
+			log.Debugf("Event stream client has disconnected")
+			return nil
+		case <-c.ctx.Done(): // otherwise server shutdown would wait for the stream forever
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(resp, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if !filter.matches(&evt) || !c.canSee(ec, &evt) {
+				continue
+			}
+
+			data, err := json.Marshal(evt)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", evt.Type, data); err != nil {
+				return err
+			}
+		}
+		resp.Flush()
+	}
+}
+
+type streamFilter struct {
+	kind      string // either object kind or tracker class, like "managed"
+	namespace string
+	name      string
+}
+
+func (f *streamFilter) matches(evt *tracker.Event) bool {
+	if f.kind != "" && !strings.EqualFold(f.kind, evt.Kind) && !strings.EqualFold(f.kind, string(evt.Class)) {
+		return false
+	}
+
+	if f.namespace != "" && f.namespace != evt.Namespace {
+		return false
+	}
+
+	if f.name != "" && f.name != evt.Name {
+		return false
+	}
+
+	return true
+}
+
+// canSee hides the changes of objects that impersonated viewer is not allowed to list
+func (c *Controller) canSee(ec echo.Context, evt *tracker.Event) bool {
+	user := auth.UserFrom(ec)
+	if user == nil || !c.StatusInfo.Impersonate {
+		return true
+	}
+
+	gvr, found := c.Tracker.ResourceFor(schema.FromAPIVersionAndKind(evt.APIVersion, evt.Kind).GroupKind())
+	return found && c.access.canList(ec.Request().Context(), user, gvr, evt.Namespace)
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"net/http"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	viewFull    = ""
+	viewSummary = "summary"
+
+	// labels that Crossplane puts onto composed resources
+	labelComposite      = "crossplane.io/composite"
+	labelClaimName      = "crossplane.io/claim-name"
+	labelClaimNamespace = "crossplane.io/claim-namespace"
+)
+
+// ResourceSummary is a compact representation of claim, XR or MR, with stable schema
+type ResourceSummary struct {
+	Group             string            `json:"group"`
+	Version           string            `json:"version"`
+	Kind              string            `json:"kind"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Name              string            `json:"name"`
+	Ready             *ConditionSummary `json:"ready,omitempty"`
+	Synced            *ConditionSummary `json:"synced,omitempty"`
+	CreationTimestamp metav1.Time       `json:"creationTimestamp"`
+	Age               string            `json:"age"`
+	ProviderConfigRef string            `json:"providerConfigRef,omitempty"`
+	CompositeResource *ObjectRef        `json:"compositeResource,omitempty"`
+	Claim             *ObjectRef        `json:"claim,omitempty"`
+	ExternalName      string            `json:"externalName,omitempty"`
+}
+
+type ConditionSummary struct {
+	Status             string      `json:"status"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type ObjectRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type SummaryList struct {
+	Metadata  metav1.ListMeta   `json:"metadata"`
+	Items     []ResourceSummary `json:"items"`
+	Forbidden []string          `json:"forbidden,omitempty"` // kinds hidden from the viewer by RBAC
+	Warnings  []Warning         `json:"warnings,omitempty"`  // kinds listed partially
+}
+
+func parseView(ec echo.Context) (string, error) {
+	view := ec.QueryParam("view")
+	if view != viewFull && view != viewSummary {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "unsupported view: "+view)
+	}
+	return view, nil
+}
+
+func summarizeList(list *unstructured.UnstructuredList) *SummaryList {
+	res := SummaryList{
+		Metadata: metav1.ListMeta{
+			Continue:           list.GetContinue(),
+			RemainingItemCount: list.GetRemainingItemCount(),
+		},
+		Items: make([]ResourceSummary, 0, len(list.Items)),
+	}
+
+	now := time.Now()
+	for i := range list.Items {
+		res.Items = append(res.Items, summarize(&list.Items[i], now))
+	}
+	return &res
+}
+
+func summarize(obj *unstructured.Unstructured, now time.Time) ResourceSummary {
+	gvk := obj.GroupVersionKind()
+	res := ResourceSummary{
+		Group:             gvk.Group,
+		Version:           gvk.Version,
+		Kind:              gvk.Kind,
+		Namespace:         obj.GetNamespace(),
+		Name:              obj.GetName(),
+		Ready:             conditionSummary(obj, xpv1.TypeReady),
+		Synced:            conditionSummary(obj, xpv1.TypeSynced),
+		CreationTimestamp: obj.GetCreationTimestamp(),
+		Age:               duration.HumanDuration(now.Sub(obj.GetCreationTimestamp().Time)),
+		ExternalName:      meta.GetExternalName(obj),
+	}
+
+	res.ProviderConfigRef, _, _ = unstructured.NestedString(obj.Object, "spec", "providerConfigRef", "name")
+
+	// claims point to XR via resourceRef, while composed resources are controlled by XR
+	if ref := nestedRef(obj, "spec", "resourceRef"); ref != nil {
+		res.CompositeResource = ref
+	} else if owner := metav1.GetControllerOf(obj); owner != nil {
+		res.CompositeResource = &ObjectRef{APIVersion: owner.APIVersion, Kind: owner.Kind, Name: owner.Name}
+	} else if name := obj.GetLabels()[labelComposite]; name != "" && name != obj.GetName() {
+		res.CompositeResource = &ObjectRef{Name: name}
+	}
+
+	if name := obj.GetLabels()[labelClaimName]; name != "" {
+		res.Claim = &ObjectRef{Namespace: obj.GetLabels()[labelClaimNamespace], Name: name}
+	} else if ref := nestedRef(obj, "spec", "claimRef"); ref != nil {
+		res.Claim = ref
+	} else if ref := nestedRef(obj, "spec", "crossplane", "claimRef"); ref != nil {
+		res.Claim = ref
+	}
+
+	return res
+}
+
+func conditionSummary(obj *unstructured.Unstructured, typ xpv1.ConditionType) *ConditionSummary {
+	conds, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, cond := range conds {
+		c, ok := cond.(map[string]interface{})
+		if !ok || c["type"] != string(typ) {
+			continue
+		}
+
+		res := ConditionSummary{}
+		res.Status, _, _ = unstructured.NestedString(c, "status")
+		res.Reason, _, _ = unstructured.NestedString(c, "reason")
+		res.Message, _, _ = unstructured.NestedString(c, "message")
+		if ts, _, _ := unstructured.NestedString(c, "lastTransitionTime"); ts != "" {
+			_ = res.LastTransitionTime.UnmarshalQueryParameter(ts)
+		}
+		return &res
+	}
+	return nil
+}
+
+func nestedRef(obj *unstructured.Unstructured, fields ...string) *ObjectRef {
+	ref, found, _ := unstructured.NestedMap(obj.Object, fields...)
+	if !found {
+		return nil
+	}
+
+	res := ObjectRef{}
+	res.APIVersion, _, _ = unstructured.NestedString(ref, "apiVersion")
+	res.Kind, _, _ = unstructured.NestedString(ref, "kind")
+	res.Namespace, _, _ = unstructured.NestedString(ref, "namespace")
+	res.Name, _, _ = unstructured.NestedString(ref, "name")
+	if res.Name == "" {
+		return nil
+	}
+	return &res
+}
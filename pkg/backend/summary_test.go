@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+)
+
+func TestSummarize_Managed(t *testing.T) {
+	created := time.Now().Add(-5 * time.Hour)
+	mr := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "s3.aws.upbound.io/v1beta1",
+		"kind":       "Bucket",
+		"metadata": map[string]interface{}{
+			"name":              "my-bucket-x7k2p",
+			"creationTimestamp": created.UTC().Format(time.RFC3339),
+			"annotations":       map[string]interface{}{"crossplane.io/external-name": "my-bucket-ext"},
+			"labels": map[string]interface{}{
+				"crossplane.io/composite":       "my-bucket-x7k2p",
+				"crossplane.io/claim-name":      "my-bucket",
+				"crossplane.io/claim-namespace": "dev",
+			},
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "example.org/v1", "kind": "XBucket", "name": "my-bucket-x7k2p", "uid": "1", "controller": true,
+			}},
+			"managedFields": []interface{}{map[string]interface{}{"manager": "crossplane"}},
+		},
+		"spec": map[string]interface{}{
+			"providerConfigRef": map[string]interface{}{"name": "default"},
+		},
+		"status": map[string]interface{}{
+			"atProvider": map[string]interface{}{"huge": "blob"},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Creating", "lastTransitionTime": created.UTC().Format(time.RFC3339)},
+				map[string]interface{}{"type": "Synced", "status": "True", "reason": "ReconcileSuccess"},
+			},
+		},
+	}}
+
+	res := summarize(&mr, created.Add(5*time.Hour))
+	assert.Equal(t, "s3.aws.upbound.io", res.Group)
+	assert.Equal(t, "v1beta1", res.Version)
+	assert.Equal(t, "Bucket", res.Kind)
+	assert.Equal(t, "5h", res.Age)
+	assert.Equal(t, "default", res.ProviderConfigRef)
+	assert.Equal(t, "my-bucket-ext", res.ExternalName)
+	assert.Equal(t, &ObjectRef{APIVersion: "example.org/v1", Kind: "XBucket", Name: "my-bucket-x7k2p"}, res.CompositeResource)
+	assert.Equal(t, &ObjectRef{Namespace: "dev", Name: "my-bucket"}, res.Claim)
+	require.NotNil(t, res.Ready)
+	assert.Equal(t, "False", res.Ready.Status)
+	assert.Equal(t, "Creating", res.Ready.Reason)
+	assert.Equal(t, created.Unix(), res.Ready.LastTransitionTime.Unix())
+	require.NotNil(t, res.Synced)
+	assert.Equal(t, "True", res.Synced.Status)
+}
+
+func TestSummarize_Claim(t *testing.T) {
+	claim := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "Bucket",
+		"metadata":   map[string]interface{}{"name": "my-bucket", "namespace": "dev"},
+		"spec": map[string]interface{}{
+			"resourceRef": map[string]interface{}{"apiVersion": "example.org/v1", "kind": "XBucket", "name": "my-bucket-x7k2p"},
+		},
+	}}
+
+	res := summarize(&claim, time.Now())
+	assert.Equal(t, "dev", res.Namespace)
+	assert.Equal(t, &ObjectRef{APIVersion: "example.org/v1", Kind: "XBucket", Name: "my-bucket-x7k2p"}, res.CompositeResource)
+	assert.Nil(t, res.Claim)
+	assert.Nil(t, res.Ready)
+}
+
+func TestSummarizeList(t *testing.T) {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetContinue("Mg")
+	list.SetRemainingItemCount(pointer.Int64(1))
+	list.Items = []unstructured.Unstructured{testObject("Bucket", "dev", "a", time.Minute, "True")}
+
+	res := summarizeList(list)
+	assert.Equal(t, metav1.ListMeta{Continue: "Mg", RemainingItemCount: pointer.Int64(1)}, res.Metadata)
+	assert.Len(t, res.Items, 1)
+}
@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// certCheckInterval limits how often the files are checked for changes, as the check happens on TLS handshake
+const certCheckInterval = 10 * time.Second
+
+// certReloader serves the certificate from files, picking up the changes when files are replaced,
+// like it happens with mounted Secrets that cert-manager rotates
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string // for client certificates, optional
+
+	mx       sync.Mutex
+	checked  time.Time
+	modTime  time.Time
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+}
+
+func newCertReloader(certFile string, keyFile string, caFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) files() []string {
+	res := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		res = append(res, r.caFile)
+	}
+	return res
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	res := time.Time{}
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return res, err
+		}
+
+		if info.ModTime().After(res) {
+			res = info.ModTime()
+		}
+	}
+	return res, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load TLS certificate")
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read client CA")
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("no certificates found in client CA file")
+		}
+	}
+
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTime = modTime
+	return nil
+}
+
+// reloadIfChanged keeps serving the old certificate if the new one fails to load, files might be in the middle of update
+func (r *certReloader) reloadIfChanged(now time.Time) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if now.Sub(r.checked) < certCheckInterval {
+		return
+	}
+	r.checked = now
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		log.Warnf("Failed to check TLS certificate files: %v", err)
+		return
+	}
+
+	if !modTime.After(r.modTime) {
+		return
+	}
+
+	if err := r.load(modTime); err != nil {
+		log.Warnf("Failed to reload TLS certificate, keeping the old one: %v", err)
+		return
+	}
+	log.Infof("Reloaded TLS certificate from %s", r.certFile)
+}
+
+func (r *certReloader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged(time.Now())
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.cert, nil
+}
+
+// configForClient returns a copy of base config with the current certificate and client CA
+func (r *certReloader) configForClient(base *tls.Config, hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, err := r.getCertificate(hello)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	cfg := base.Clone()
+	cfg.GetCertificate = nil
+	cfg.Certificates = []tls.Certificate{*cert}
+	cfg.ClientCAs = r.clientCA
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg, nil
+}
+
+func (r *certReloader) TLSConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// http.Server adds HTTP/2 only to its own copy of the config, the per-client one would go without it
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.getCertificate,
+	}
+
+	if r.caFile != "" {
+		base := cfg.Clone()
+		cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.configForClient(base, hello)
+		}
+	}
+	return cfg
+}
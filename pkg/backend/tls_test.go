@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, dir string, name string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		"tls.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+	for file, data := range files {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+func certName(t *testing.T, r *certReloader) string {
+	cert, err := r.getCertificate(nil)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	writeTestCert(t, dir, "first", start)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	r, err := newCertReloader(certFile, keyFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, "first", certName(t, r))
+	assert.Nil(t, r.TLSConfig().GetConfigForClient)
+
+	writeTestCert(t, dir, "second", start.Add(time.Minute))
+	assert.Equal(t, "first", certName(t, r), "files are not checked too often")
+
+	r.reloadIfChanged(time.Now().Add(certCheckInterval))
+	assert.Equal(t, "second", certName(t, r))
+
+	// broken file does not break serving
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0600))
+	r.reloadIfChanged(time.Now().Add(2 * certCheckInterval))
+	assert.Equal(t, "second", certName(t, r))
+}
+
+func TestCertReloader_ClientCA(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCert(t, dir, "server", time.Now())
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	_, err := newCertReloader(certFile, keyFile, keyFile)
+	assert.Error(t, err, "key is not a CA")
+
+	r, err := newCertReloader(certFile, keyFile, certFile)
+	require.NoError(t, err)
+
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+	assert.Len(t, cfg.Certificates, 1)
+	assert.Equal(t, []string{"h2", "http/1.1"}, cfg.NextProtos, "HTTP/2 stays on with client certificates")
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+}
@@ -0,0 +1,392 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+
+	cpk8s "github.com/crossplane-contrib/provider-kubernetes/apis/v1alpha1"
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	uxres "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/komodorio/komoplane/pkg/backend/graph"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	v12 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	formatJSON    = "json"
+	formatDOT     = "dot"
+	formatMermaid = "mermaid"
+
+	mimeDOT = "text/vnd.graphviz; charset=utf-8"
+)
+
+func (c *Controller) GetGraph(ec echo.Context) error {
+	format := ec.QueryParam("format")
+	if format != "" && format != formatJSON && format != formatDOT && format != formatMermaid {
+		return echo.NewHTTPError(http.StatusBadRequest, "unsupported format: "+format)
+	}
+
+	ref := v12.ObjectReference{Namespace: ec.Param("namespace"), Name: ec.Param("name")}
+	ref.SetGroupVersionKind(schema.GroupVersionKind{Group: ec.Param("group"), Version: ec.Param("version"), Kind: ec.Param("kind")})
+	if err := c.inScope(ref.Namespace); err != nil {
+		return err
+	}
+
+	xrds, err := c.cachedListXRDs(ec)
+	if err != nil {
+		return err
+	}
+
+	builder := &graphBuilder{
+		ctx:   c.reqCtx(ec),
+		graph: graph.New(),
+		xrds:  xrds,
+		fetch: func(ctx context.Context, ref *v12.ObjectReference) (*unstructured.Unstructured, error) {
+			obj := uxres.New()
+			err := c.getDynamicResource(ctx, ref, obj)
+			return &obj.Unstructured, err
+		},
+		secrets: newSecretChecker(c.secrets, c.callTimeout),
+	}
+	builder.loadProviders = func() {
+		c.loadGraphProviders(ec, builder)
+	}
+
+	root, err := builder.fetch(builder.ctx, &ref)
+	if err != nil {
+		return err
+	}
+
+	builder.build(root)
+
+	switch format {
+	case formatDOT:
+		return ec.Blob(http.StatusOK, mimeDOT, []byte(builder.graph.DOT()))
+	case formatMermaid:
+		return ec.String(http.StatusOK, builder.graph.Mermaid())
+	default:
+		return ec.JSONPretty(http.StatusOK, builder.graph, "  ")
+	}
+}
+
+// loadGraphProviders fills provider details, only needed when there are MRs in the graph
+func (c *Controller) loadGraphProviders(ec echo.Context, builder *graphBuilder) {
+	var err error
+	builder.providers, err = c.providersByKind(builder.ctx)
+	if err != nil {
+		log.Debugf("Failed to get providers of kinds: %v", err)
+	}
+
+	builder.health, err = c.providersHealth(ec)
+	if err != nil {
+		log.Debugf("Failed to get health of providers: %v", err)
+	}
+
+	builder.configKinds = map[string]schema.GroupVersionKind{}
+	allProvCRDs, err := c.LoadCRDs(ec)
+	if err != nil {
+		log.Debugf("Failed to get CRDs of providers: %v", err)
+	}
+	for prov, crds := range allProvCRDs {
+		for _, crd := range crds {
+			if crd.Spec.Names.Kind == cpk8s.ProviderConfigKind {
+				builder.configKinds[prov] = schema.GroupVersionKind{Group: crd.Spec.Group, Version: crd.Spec.Versions[0].Name, Kind: crd.Spec.Names.Kind}
+			}
+		}
+	}
+}
+
+// graphBuilder walks from the topmost parent of an object down to MRs and everything they use
+type graphBuilder struct {
+	ctx     context.Context
+	graph   *graph.Graph
+	xrds    *cpext.CompositeResourceDefinitionList
+	fetch   func(ctx context.Context, ref *v12.ObjectReference) (*unstructured.Unstructured, error)
+	secrets *secretChecker
+
+	loadProviders func()
+	providers     map[schema.GroupKind]string        // provider name by MR kind
+	health        map[string]*ProviderHealth         // by provider name
+	configKinds   map[string]schema.GroupVersionKind // ProviderConfig kind by provider name
+}
+
+func (b *graphBuilder) build(root *unstructured.Unstructured) {
+	top := b.ascend(root)
+	b.walk(top, nil)
+	b.graph.Root = graph.NodeID(root.GetAPIVersion(), root.GetKind(), root.GetNamespace(), root.GetName())
+}
+
+// ascend finds the claim or the topmost XR above the object, so the graph shows the whole tree
+func (b *graphBuilder) ascend(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	visited := map[string]bool{}
+	for {
+		visited[graphID(obj)] = true
+
+		parentRef := b.parentOf(obj)
+		if parentRef == nil || visited[graph.NodeID(parentRef.APIVersion, parentRef.Kind, parentRef.Namespace, parentRef.Name)] {
+			return obj
+		}
+
+		parent, err := b.fetch(b.ctx, parentRef)
+		if err != nil {
+			log.Debugf("Failed to get parent %s %s: %v", parentRef.Kind, parentRef.Name, err)
+			return obj
+		}
+		obj = parent
+	}
+}
+
+func (b *graphBuilder) parentOf(obj *unstructured.Unstructured) *v12.ObjectReference {
+	if b.typeOf(obj.GetAPIVersion(), obj.GetKind()) == graph.TypeComposite {
+		if ref := nestedRef(obj, "spec", "claimRef"); ref != nil && ref.Name != "" {
+			return &v12.ObjectReference{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+		}
+	}
+
+	if owner := metav1.GetControllerOf(obj); owner != nil && b.typeOf(owner.APIVersion, owner.Kind) == graph.TypeComposite {
+		return &v12.ObjectReference{APIVersion: owner.APIVersion, Kind: owner.Kind, Name: owner.Name}
+	}
+	return nil
+}
+
+// walk adds the object with its relations, each object is visited once so cycles end here
+func (b *graphBuilder) walk(obj *unstructured.Unstructured, fetchErr error) *graph.Node {
+	typ := b.typeOf(obj.GetAPIVersion(), obj.GetKind())
+	node, added := b.graph.Add(objectNode(obj, typ, fetchErr))
+	if !added || fetchErr != nil {
+		return node
+	}
+
+	switch typ {
+	case graph.TypeClaim:
+		b.addXRD(node, obj)
+		if ref, found, _ := unstructured.NestedMap(obj.Object, "spec", "resourceRef"); found {
+			b.graph.Connect(node, b.walkRef(objectReference(ref)), graph.RelComposite)
+		}
+	case graph.TypeComposite:
+		b.addXRD(node, obj)
+		b.addComposition(node, obj)
+
+		refs, found, _ := unstructured.NestedSlice(obj.Object, "spec", "resourceRefs")
+		if !found { // Crossplane v2 keeps them under spec.crossplane
+			refs, _, _ = unstructured.NestedSlice(obj.Object, "spec", "crossplane", "resourceRefs")
+		}
+		for _, item := range refs {
+			if ref, ok := item.(map[string]interface{}); ok {
+				child := objectReference(ref)
+				if child.Namespace == "" && obj.GetNamespace() != "" {
+					child.Namespace = obj.GetNamespace() // namespaced XRs compose into their namespace
+				}
+				b.graph.Connect(node, b.walkRef(child), graph.RelComposes)
+			}
+		}
+	case graph.TypeManaged:
+		b.addProvider(node, obj)
+	}
+
+	b.addSecret(node, obj, graph.RelSecret, "spec", "writeConnectionSecretToRef")
+	return node
+}
+
+func (b *graphBuilder) walkRef(ref v12.ObjectReference) *graph.Node {
+	if existing := b.graph.Get(graph.NodeID(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)); existing != nil {
+		return existing
+	}
+
+	obj, err := b.fetch(b.ctx, &ref)
+	return b.walk(obj, err)
+}
+
+func (b *graphBuilder) typeOf(apiVersion string, kind string) graph.NodeType {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+	if xrd := b.xrdOf(gv.Group, kind); xrd != nil {
+		if xrd.Spec.Names.Kind == kind {
+			return graph.TypeComposite
+		}
+		return graph.TypeClaim
+	}
+	return graph.TypeManaged
+}
+
+func (b *graphBuilder) xrdOf(group string, kind string) *cpext.CompositeResourceDefinition {
+	for i := range b.xrds.Items {
+		xrd := &b.xrds.Items[i]
+		if xrd.Spec.Group != group {
+			continue
+		}
+		if xrd.Spec.Names.Kind == kind || (xrd.Spec.ClaimNames != nil && xrd.Spec.ClaimNames.Kind == kind) {
+			return xrd
+		}
+	}
+	return nil
+}
+
+func (b *graphBuilder) addXRD(node *graph.Node, obj *unstructured.Unstructured) {
+	xrd := b.xrdOf(obj.GroupVersionKind().Group, obj.GetKind())
+	if xrd == nil {
+		return
+	}
+
+	xrdNode := &graph.Node{
+		Type:       graph.TypeXRD,
+		APIVersion: cpext.CompositeResourceDefinitionGroupVersionKind.GroupVersion().String(),
+		Kind:       cpext.CompositeResourceDefinitionKind,
+		Name:       xrd.Name,
+		Status:     graph.StatusHealthy,
+	}
+	if cond := xrd.Status.GetCondition(cpext.TypeEstablished); cond.Status != v12.ConditionTrue {
+		xrdNode.Status = graph.StatusUnhealthy
+		xrdNode.Reason = string(cond.Reason)
+		xrdNode.Message = cond.Message
+	}
+
+	xrdNode, _ = b.graph.Add(xrdNode)
+	b.graph.Connect(node, xrdNode, graph.RelDefinedBy)
+}
+
+func (b *graphBuilder) addComposition(node *graph.Node, obj *unstructured.Unstructured) {
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "compositionRef", "name")
+	if name == "" {
+		name, _, _ = unstructured.NestedString(obj.Object, "spec", "crossplane", "compositionRef", "name")
+	}
+	if name == "" {
+		return
+	}
+
+	ref := &v12.ObjectReference{Name: name}
+	ref.SetGroupVersionKind(cpext.CompositionGroupVersionKind)
+	if existing := b.graph.Get(graph.NodeID(ref.APIVersion, ref.Kind, "", name)); existing != nil {
+		b.graph.Connect(node, existing, graph.RelComposition)
+		return
+	}
+
+	comp, err := b.fetch(b.ctx, ref)
+	compNode, _ := b.graph.Add(objectNode(comp, graph.TypeComposition, err))
+	b.graph.Connect(node, compNode, graph.RelComposition)
+}
+
+func (b *graphBuilder) addProvider(node *graph.Node, obj *unstructured.Unstructured) {
+	if b.providers == nil && b.loadProviders != nil {
+		b.loadProviders()
+		b.loadProviders = nil
+	}
+
+	prov, found := b.providers[obj.GroupVersionKind().GroupKind()]
+	if !found {
+		return
+	}
+
+	provNode := &graph.Node{
+		Type:       graph.TypeProvider,
+		APIVersion: cpv1.ProviderGroupVersionKind.GroupVersion().String(),
+		Kind:       cpv1.ProviderKind,
+		Name:       prov,
+		Status:     graph.StatusUnknown,
+	}
+	if health, found := b.health[prov]; found {
+		provNode.Status = graph.StatusHealthy
+		if !health.Healthy {
+			provNode.Status = graph.StatusUnhealthy
+			provNode.Message = health.Problem
+		}
+	}
+	provNode, _ = b.graph.Add(provNode)
+
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "providerConfigRef", "name")
+	gvk, known := b.configKinds[prov]
+	if name == "" || !known {
+		b.graph.Connect(node, provNode, graph.RelProvider)
+		return
+	}
+
+	ref := &v12.ObjectReference{Name: name}
+	ref.SetGroupVersionKind(gvk)
+	pcNode := b.graph.Get(graph.NodeID(ref.APIVersion, ref.Kind, "", name))
+	if pcNode == nil {
+		pc, err := b.fetch(b.ctx, ref)
+		var added bool
+		pcNode, added = b.graph.Add(objectNode(pc, graph.TypeProviderConfig, err))
+		if added && err == nil {
+			b.graph.Connect(pcNode, provNode, graph.RelProvider)
+			if source, _, _ := unstructured.NestedString(pc.Object, "spec", "credentials", "source"); source == credsSecret {
+				b.addSecret(pcNode, pc, graph.RelCredentials, "spec", "credentials", "secretRef")
+			}
+		}
+	}
+	b.graph.Connect(node, pcNode, graph.RelProviderConfig)
+}
+
+func (b *graphBuilder) addSecret(node *graph.Node, obj *unstructured.Unstructured, relation string, fields ...string) {
+	ref, found, _ := unstructured.NestedMap(obj.Object, fields...)
+	if !found {
+		return
+	}
+
+	name, _, _ := unstructured.NestedString(ref, "name")
+	namespace, _, _ := unstructured.NestedString(ref, "namespace")
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+
+	secretNode := &graph.Node{Type: graph.TypeSecret, APIVersion: "v1", Kind: "Secret", Namespace: namespace, Name: name}
+	if existing := b.graph.Get(graph.NodeID(secretNode.APIVersion, secretNode.Kind, namespace, name)); existing != nil {
+		b.graph.Connect(node, existing, relation)
+		return
+	}
+
+	status := b.secrets.check(b.ctx, namespace, name)
+	switch {
+	case status.Exists:
+		secretNode.Status = graph.StatusHealthy
+	case status.Problem == "secret not found":
+		secretNode.Status = graph.StatusMissing
+	default:
+		secretNode.Status = graph.StatusUnknown
+		secretNode.Message = status.Problem
+	}
+
+	secretNode, _ = b.graph.Add(secretNode)
+	b.graph.Connect(node, secretNode, relation)
+}
+
+func graphID(obj *unstructured.Unstructured) string {
+	return graph.NodeID(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// objectNode derives the status from conditions, objects without Ready condition are healthy once they exist
+func objectNode(obj *unstructured.Unstructured, typ graph.NodeType, fetchErr error) *graph.Node {
+	res := &graph.Node{
+		ID:         graphID(obj),
+		Type:       typ,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Status:     graph.StatusUnknown,
+	}
+
+	switch {
+	case k8sErrors.IsNotFound(fetchErr):
+		res.Status = graph.StatusMissing
+	case k8sErrors.IsForbidden(fetchErr):
+		res.Reason = "Forbidden"
+	case fetchErr != nil:
+		res.Message = fetchErr.Error()
+	default:
+		if cond := failedCondition(obj); cond != nil {
+			res.Status = graph.StatusUnhealthy
+			res.Reason = string(cond.Reason)
+			res.Message = cond.Message
+		} else if ready := conditionSummary(obj, xpv1.TypeReady); ready == nil || ready.Status == string(v12.ConditionTrue) {
+			res.Status = graph.StatusHealthy
+		}
+	}
+	return res
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/komodorio/komoplane/pkg/backend/graph"
+	"github.com/stretchr/testify/assert"
+	v12 "k8s.io/api/core/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+)
+
+func graphObj(apiVersion string, kind string, namespace string, name string, spec map[string]interface{}, conds ...map[string]interface{}) *unstructured.Unstructured {
+	obj := diagObj(kind, name, conds...)
+	obj.SetAPIVersion(apiVersion)
+	obj.SetNamespace(namespace)
+	obj.Object["spec"] = spec
+	return obj
+}
+
+func refTo(apiVersion string, kind string, name string) map[string]interface{} {
+	return map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "name": name}
+}
+
+func testGraphBuilder(objects ...*unstructured.Unstructured) (*graphBuilder, *int) {
+	xrd := cpext.CompositeResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "xbuckets.example.org"},
+		Spec: cpext.CompositeResourceDefinitionSpec{
+			Group:      "example.org",
+			Names:      apiextv1.CustomResourceDefinitionNames{Kind: "XBucket"},
+			ClaimNames: &apiextv1.CustomResourceDefinitionNames{Kind: "Bucket"},
+		},
+	}
+	xrd.Status.SetConditions(xpv1.Condition{Type: cpext.TypeEstablished, Status: v12.ConditionTrue})
+
+	byID := map[string]*unstructured.Unstructured{}
+	for _, obj := range objects {
+		byID[graphID(obj)] = obj
+	}
+
+	fetches := 0
+	return &graphBuilder{
+		ctx:   context.Background(),
+		graph: graph.New(),
+		xrds:  &cpext.CompositeResourceDefinitionList{Items: []cpext.CompositeResourceDefinition{xrd}},
+		fetch: func(_ context.Context, ref *v12.ObjectReference) (*unstructured.Unstructured, error) {
+			fetches++
+			if obj, found := byID[graph.NodeID(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)]; found {
+				return obj, nil
+			}
+
+			res := &unstructured.Unstructured{}
+			res.SetAPIVersion(ref.APIVersion)
+			res.SetKind(ref.Kind)
+			res.SetNamespace(ref.Namespace)
+			res.SetName(ref.Name)
+			return res, k8sErrors.NewNotFound(schema.GroupResource{Resource: ref.Kind}, ref.Name)
+		},
+		secrets:     testSecrets(),
+		providers:   map[schema.GroupKind]string{{Group: "s3.aws.upbound.io", Kind: "Bucket"}: "provider-aws-s3"},
+		health:      map[string]*ProviderHealth{"provider-aws-s3": {Name: "provider-aws-s3", Healthy: true}},
+		configKinds: map[string]schema.GroupVersionKind{"provider-aws-s3": {Group: "aws.upbound.io", Version: "v1beta1", Kind: "ProviderConfig"}},
+	}, &fetches
+}
+
+func TestGraphBuilder(t *testing.T) {
+	claim := graphObj("example.org/v1", "Bucket", "crossplane-system", "data", map[string]interface{}{
+		"resourceRef":                refTo("example.org/v1", "XBucket", "data-x1"),
+		"writeConnectionSecretToRef": map[string]interface{}{"name": "aws-creds"},
+	}, cond("Ready", "False", "Creating"))
+
+	xr := graphObj("example.org/v1", "XBucket", "", "data-x1", map[string]interface{}{
+		"claimRef":       map[string]interface{}{"apiVersion": "example.org/v1", "kind": "Bucket", "namespace": "crossplane-system", "name": "data"},
+		"compositionRef": map[string]interface{}{"name": "missing-composition"},
+		"resourceRefs": []interface{}{
+			refTo("s3.aws.upbound.io/v1beta1", "Bucket", "data-bucket"),
+			refTo("example.org/v1", "XBucket", "data-x1"), // a cycle
+			refTo("s3.aws.upbound.io/v1beta1", "BucketPolicy", "gone"),
+		},
+	}, cond("Ready", "False", "Creating"))
+
+	mr := graphObj("s3.aws.upbound.io/v1beta1", "Bucket", "", "data-bucket", map[string]interface{}{
+		"providerConfigRef": map[string]interface{}{"name": "default"},
+	}, cond("Synced", "False", "ReconcileError"))
+	mr.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.org/v1", Kind: "XBucket", Name: "data-x1", Controller: pointer.Bool(true)}})
+
+	pc := graphObj("aws.upbound.io/v1beta1", "ProviderConfig", "", "default", map[string]interface{}{"credentials": secretCreds("aws-creds", "credentials")})
+
+	builder, fetches := testGraphBuilder(claim, xr, mr, pc)
+	builder.build(mr) // starting from MR, the whole tree is shown
+	g := builder.graph
+
+	assert.Equal(t, "s3.aws.upbound.io/Bucket/data-bucket", g.Root)
+	assert.Equal(t, "example.org/Bucket/crossplane-system/data", g.Nodes[0].ID, "the claim is on top")
+
+	statuses := map[string]graph.Status{}
+	for _, node := range g.Nodes {
+		statuses[node.ID] = node.Status
+	}
+	assert.Equal(t, map[string]graph.Status{
+		"example.org/Bucket/crossplane-system/data":                                    graph.StatusUnhealthy,
+		"apiextensions.crossplane.io/CompositeResourceDefinition/xbuckets.example.org": graph.StatusHealthy,
+		"example.org/XBucket/data-x1":                                                  graph.StatusUnhealthy,
+		"apiextensions.crossplane.io/Composition/missing-composition":                  graph.StatusMissing,
+		"s3.aws.upbound.io/Bucket/data-bucket":                                         graph.StatusUnhealthy,
+		"pkg.crossplane.io/Provider/provider-aws-s3":                                   graph.StatusHealthy,
+		"aws.upbound.io/ProviderConfig/default":                                        graph.StatusHealthy,
+		"core/Secret/crossplane-system/aws-creds":                                      graph.StatusHealthy,
+		"s3.aws.upbound.io/BucketPolicy/gone":                                          graph.StatusMissing,
+	}, statuses)
+
+	relations := map[string]int{}
+	for _, edge := range g.Edges {
+		relations[edge.Relation]++
+	}
+	assert.Equal(t, map[string]int{
+		graph.RelComposite:      1,
+		graph.RelDefinedBy:      2,
+		graph.RelComposition:    1,
+		graph.RelComposes:       3,
+		graph.RelProviderConfig: 1,
+		graph.RelProvider:       1,
+		graph.RelSecret:         1,
+		graph.RelCredentials:    1,
+	}, relations)
+
+	assert.Equal(t, 7, *fetches, "the cycle does not cause more fetches")
+}
@@ -0,0 +1,115 @@
+package tracker
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// discoveryInterval is how often new kinds are looked for, when there is no access to CRDs
+const discoveryInterval = time.Minute
+
+func (t *Tracker) canListCRDs() bool {
+	_, err := t.crdClient.ApiextensionsV1().CustomResourceDefinitions().List(t.ctx, metav1.ListOptions{Limit: 1})
+	if k8sErrors.IsForbidden(err) {
+		log.Debugf("Failed to list CRDs: %v", err)
+		return false
+	}
+	return true
+}
+
+func (t *Tracker) crdsSynced() bool {
+	if t.discoveryMode {
+		return t.discovered.Load()
+	}
+	return t.crdInformer.HasSynced()
+}
+
+// discover finds the kinds to track in API discovery info, which is available to everyone
+func (t *Tracker) discover(_ context.Context) {
+	lists, err := discovery.ServerPreferredResources(t.crdClient.Discovery())
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			log.Warnf("Failed to discover API resources: %v", err)
+			return
+		}
+		log.Debugf("Some of API groups failed discovery: %v", err)
+	}
+
+	found := map[string]*v1.CustomResourceDefinition{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			log.Debugf("Failed to parse group version %s: %v", list.GroupVersion, err)
+			continue
+		}
+
+		for _, res := range list.APIResources {
+			if strings.Contains(res.Name, "/") { // subresource
+				continue
+			}
+
+			crd := crdFromDiscovery(gv, res)
+			if Classify(crd) != ClassNone {
+				found[crd.Name] = crd
+			}
+		}
+	}
+
+	t.mx.Lock()
+	old := t.discoveredCRDs
+	t.discoveredCRDs = found
+	t.mx.Unlock()
+
+	changed := len(old) != len(found)
+	for name := range old {
+		if _, ok := found[name]; !ok {
+			t.untrack(name)
+			changed = true
+		}
+	}
+
+	for _, crd := range found {
+		t.onCRD(crd)
+	}
+
+	if changed {
+		t.crdsChanged()
+	}
+
+	t.discovered.Store(true)
+}
+
+// crdFromDiscovery fills just enough of CRD to classify it and start watching it
+func crdFromDiscovery(gv schema.GroupVersion, res metav1.APIResource) *v1.CustomResourceDefinition {
+	scope := v1.ClusterScoped
+	if res.Namespaced {
+		scope = v1.NamespaceScoped
+	}
+
+	return &v1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: res.Name + "." + gv.Group,
+		},
+		Spec: v1.CustomResourceDefinitionSpec{
+			Group: gv.Group,
+			Names: v1.CustomResourceDefinitionNames{
+				Kind:       res.Kind,
+				Plural:     res.Name,
+				Categories: res.Categories,
+			},
+			Scope:    scope,
+			Versions: []v1.CustomResourceDefinitionVersion{{Name: gv.Version, Served: true, Storage: true}},
+		},
+		Status: v1.CustomResourceDefinitionStatus{
+			Conditions: []v1.CustomResourceDefinitionCondition{{Type: v1.Established, Status: v1.ConditionTrue}},
+		},
+	}
+}
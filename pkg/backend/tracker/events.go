@@ -0,0 +1,113 @@
+package tracker
+
+import (
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+type EventType string
+
+const (
+	EventAdded   EventType = "added"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a notification about change of a tracked object
+type Event struct {
+	Type            EventType `json:"type"`
+	Class           Class     `json:"class"`
+	APIVersion      string    `json:"apiVersion"`
+	Kind            string    `json:"kind"`
+	Namespace       string    `json:"namespace,omitempty"`
+	Name            string    `json:"name"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+}
+
+// Subscribe returns a channel receiving events about all tracked objects, and a function to stop receiving them.
+// Events are dropped for subscribers that don't read them fast enough.
+func (t *Tracker) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	t.subsMx.Lock()
+	t.lastSub++
+	id := t.lastSub
+	t.subs[id] = ch
+	t.subsMx.Unlock()
+
+	return ch, func() {
+		t.subsMx.Lock()
+		defer t.subsMx.Unlock()
+		if _, found := t.subs[id]; found {
+			delete(t.subs, id)
+			close(ch)
+		}
+	}
+}
+
+// OnCRDsChanged calls fn each time CRDs are added, removed or their spec changes, to drop the caches based on them
+func (t *Tracker) OnCRDsChanged(fn func()) {
+	t.subsMx.Lock()
+	defer t.subsMx.Unlock()
+	t.crdHooks = append(t.crdHooks, fn)
+}
+
+func (t *Tracker) crdsChanged() {
+	t.subsMx.RLock()
+	defer t.subsMx.RUnlock()
+
+	for _, fn := range t.crdHooks {
+		fn()
+	}
+}
+
+func (t *Tracker) publish(evt Event) {
+	t.subsMx.RLock()
+	defer t.subsMx.RUnlock()
+
+	for id, ch := range t.subs {
+		select {
+		case ch <- evt:
+		default:
+			log.Debugf("Subscriber %d is too slow, dropped event for %s/%s", id, evt.Kind, evt.Name)
+		}
+	}
+}
+
+func (t *Tracker) eventHandler(class Class) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			t.publishObj(EventAdded, class, obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			if o, ok := old.(*unstructured.Unstructured); ok && o.GetResourceVersion() == obj.(*unstructured.Unstructured).GetResourceVersion() {
+				return // nothing has changed
+			}
+			t.publishObj(EventUpdated, class, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			t.publishObj(EventDeleted, class, obj)
+		},
+	}
+}
+
+func (t *Tracker) publishObj(typ EventType, class Class, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	t.publish(Event{
+		Type:            typ,
+		Class:           class,
+		APIVersion:      u.GetAPIVersion(),
+		Kind:            u.GetKind(),
+		Namespace:       u.GetNamespace(),
+		Name:            u.GetName(),
+		ResourceVersion: u.GetResourceVersion(),
+	})
+}
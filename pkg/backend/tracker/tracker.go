@@ -0,0 +1,467 @@
+package tracker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cpk8s "github.com/crossplane-contrib/provider-kubernetes/apis/v1alpha1"
+	cpext "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	cpv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Class tells what role the objects of a CRD play in Crossplane
+type Class string
+
+const (
+	ClassNone        Class = ""
+	ClassManaged     Class = "managed"
+	ClassComposite   Class = "composite"
+	ClassClaim       Class = "claim"
+	ClassProvider    Class = "provider"
+	ClassXRD         Class = "xrd"
+	ClassComposition Class = "composition"
+)
+
+// categories that Crossplane puts onto CRDs it generates from XRDs
+const (
+	categoryClaim     = "claim"
+	categoryComposite = "composite"
+	categoryManaged   = "managed" // not generated, but all the providers follow the convention
+)
+
+// Tracker watches CRDs in the cluster and keeps an informer running for every kind of MR, XR and claim,
+// as well as for providers, XRDs and compositions, so that listing those objects does not require a round-trip
+// to the API server. With namespace set, namespaced kinds are only watched in that namespace.
+type Tracker struct {
+	ctx         context.Context
+	crdClient   clientset.Interface
+	dynamic     dynamic.Interface
+	crdInformer cache.SharedIndexInformer
+	namespace   string
+	syncTimeout time.Duration
+
+	discoveryMode bool // when not allowed to list CRDs
+	discovered    atomic.Bool
+
+	mx             sync.RWMutex
+	kinds          map[string]*trackedKind // keyed by CRD name
+	discoveredCRDs map[string]*v1.CustomResourceDefinition
+
+	subsMx   sync.RWMutex
+	subs     map[int]chan Event
+	lastSub  int
+	crdHooks []func()
+}
+
+type trackedKind struct {
+	class     Class
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	informer  cache.SharedIndexInformer
+	stop      chan struct{}
+	forbidden atomic.Bool // such kinds are treated as empty
+}
+
+func New(ctx context.Context, cfg *rest.Config, namespace string, syncTimeout time.Duration) (*Tracker, error) {
+	crdClient, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTracker(ctx, crdClient, dyn, namespace, syncTimeout), nil
+}
+
+func newTracker(ctx context.Context, crdClient clientset.Interface, dyn dynamic.Interface, namespace string, syncTimeout time.Duration) *Tracker {
+	factory := apiextinformers.NewSharedInformerFactory(crdClient, 0)
+
+	t := &Tracker{
+		ctx:            ctx,
+		crdClient:      crdClient,
+		dynamic:        dyn,
+		crdInformer:    factory.Apiextensions().V1().CustomResourceDefinitions().Informer(),
+		namespace:      namespace,
+		syncTimeout:    syncTimeout,
+		kinds:          map[string]*trackedKind{},
+		discoveredCRDs: map[string]*v1.CustomResourceDefinition{},
+		subs:           map[int]chan Event{},
+	}
+
+	_, _ = t.crdInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			t.onCRD(obj)
+			t.crdsChanged()
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			t.onCRD(obj)
+			// the kind gets served only once established, which is a status update
+			prev, crd := old.(*v1.CustomResourceDefinition), obj.(*v1.CustomResourceDefinition)
+			if prev.Generation != crd.Generation || isEstablished(prev) != isEstablished(crd) {
+				t.crdsChanged()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			t.onCRDDeleted(obj)
+			t.crdsChanged()
+		},
+	})
+
+	return t
+}
+
+// Start runs the CRD watch and waits for a limited time until the initial list of CRDs is received.
+// If namespace-limited user can't list CRDs, the kinds are periodically discovered via API discovery instead.
+func (t *Tracker) Start() {
+	if t.namespace != "" && !t.canListCRDs() {
+		log.Infof("Not allowed to list CRDs, will find resource kinds via API discovery")
+		t.discoveryMode = true
+		go wait.UntilWithContext(t.ctx, t.discover, discoveryInterval)
+	} else {
+		go t.crdInformer.Run(t.ctx.Done())
+	}
+
+	go func() {
+		<-t.ctx.Done()
+		t.stopAll()
+	}()
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.syncTimeout)
+	defer cancel()
+
+	if !cache.WaitForCacheSync(ctx.Done(), t.crdsSynced) {
+		log.Warnf("Resource tracker did not receive the list of CRDs in %s, will keep trying in background", t.syncTimeout)
+		return
+	}
+
+	t.mx.RLock()
+	log.Infof("Resource tracker has started, watching %d kinds", len(t.kinds))
+	t.mx.RUnlock()
+}
+
+// CRDs returns all CRDs known in the cluster, objects are shared and must not be modified.
+// In discovery mode, those are incomplete CRDs made from discovery info.
+func (t *Tracker) CRDs(ctx context.Context) []*v1.CustomResourceDefinition {
+	t.waitForSync(ctx, nil)
+
+	if t.discoveryMode {
+		t.mx.RLock()
+		defer t.mx.RUnlock()
+
+		res := make([]*v1.CustomResourceDefinition, 0, len(t.discoveredCRDs))
+		for _, crd := range t.discoveredCRDs {
+			res = append(res, crd)
+		}
+		return res
+	}
+
+	objs := t.crdInformer.GetStore().List()
+	res := make([]*v1.CustomResourceDefinition, 0, len(objs))
+	for _, obj := range objs {
+		res = append(res, obj.(*v1.CustomResourceDefinition))
+	}
+	return res
+}
+
+// List returns all objects of the given class, waiting for a limited time for the informers that did not sync yet.
+// Returned objects are shared with the informer caches and must not be modified.
+func (t *Tracker) List(ctx context.Context, class Class) []unstructured.Unstructured {
+	t.waitForSync(ctx, nil)
+	kinds := t.kindsOf(class)
+	t.waitForSync(ctx, kinds)
+
+	res := []unstructured.Unstructured{}
+	for _, kind := range kinds {
+		for _, obj := range kind.informer.GetStore().List() {
+			res = append(res, *obj.(*unstructured.Unstructured))
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.GetAPIVersion() != b.GetAPIVersion() {
+			return a.GetAPIVersion() < b.GetAPIVersion()
+		}
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+
+	return res
+}
+
+// ResourceFor returns the watched resource of the kind, if it is tracked
+func (t *Tracker) ResourceFor(gk schema.GroupKind) (schema.GroupVersionResource, bool) {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	for _, kind := range t.kinds {
+		if kind.gvr.Group == gk.Group && kind.kind == gk.Kind {
+			return kind.gvr, true
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// ClassOf tells the class of tracked kind, ClassNone for kinds not tracked
+func (t *Tracker) ClassOf(gk schema.GroupKind) Class {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	for _, kind := range t.kinds {
+		if kind.gvr.Group == gk.Group && kind.kind == gk.Kind {
+			return kind.class
+		}
+	}
+	return ClassNone
+}
+
+// Unsynced returns the kinds of the class which informers did not sync yet, so their objects are listed partially
+func (t *Tracker) Unsynced(class Class) []schema.GroupKind {
+	res := []schema.GroupKind{}
+	for _, kind := range t.kindsOf(class) {
+		if !kind.informer.HasSynced() && !kind.forbidden.Load() {
+			res = append(res, schema.GroupKind{Group: kind.gvr.Group, Kind: kind.kind})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+func (t *Tracker) kindsOf(class Class) []*trackedKind {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	res := []*trackedKind{}
+	for _, kind := range t.kinds {
+		if kind.class == class {
+			res = append(res, kind)
+		}
+	}
+	return res
+}
+
+func (t *Tracker) waitForSync(ctx context.Context, kinds []*trackedKind) {
+	synced := []cache.InformerSynced{}
+	if !t.crdsSynced() {
+		synced = append(synced, t.crdsSynced)
+	}
+
+	for _, kind := range kinds {
+		if !kind.informer.HasSynced() && !kind.forbidden.Load() {
+			synced = append(synced, kind.informer.HasSynced)
+		}
+	}
+
+	if len(synced) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.syncTimeout)
+	defer cancel()
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		log.Warnf("Some of %d watched kinds did not sync in time, results are partial", len(synced))
+	}
+}
+
+func (t *Tracker) onCRD(obj interface{}) {
+	crd, ok := obj.(*v1.CustomResourceDefinition)
+	if !ok {
+		return
+	}
+
+	class := Classify(crd)
+	version := servedVersion(crd)
+	if class == ClassNone || version == "" || !isEstablished(crd) {
+		t.untrack(crd.Name)
+		return
+	}
+
+	gvr := schema.GroupVersionResource{
+		Group:    crd.Spec.Group,
+		Version:  version,
+		Resource: crd.Spec.Names.Plural,
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if existing, found := t.kinds[crd.Name]; found {
+		if existing.gvr == gvr && existing.class == class {
+			return
+		}
+		close(existing.stop)
+	}
+
+	namespace := metav1.NamespaceAll
+	if crd.Spec.Scope == v1.NamespaceScoped {
+		namespace = t.namespace
+	}
+
+	log.Debugf("Starting to watch %s as %s", gvr, class)
+	kind := &trackedKind{
+		class:     class,
+		gvr:       gvr,
+		kind:      crd.Spec.Names.Kind,
+		namespace: namespace,
+		stop:      make(chan struct{}),
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(t.dynamic, gvr, namespace, 0, cache.Indexers{}, nil).Informer()
+	_ = informer.SetTransform(stripManagedFields)
+	_ = informer.SetWatchErrorHandler(kind.onWatchError)
+	_, _ = informer.AddEventHandler(t.eventHandler(class))
+	kind.informer = informer
+	t.kinds[crd.Name] = kind
+
+	go informer.Run(kind.stop)
+}
+
+// onWatchError remembers that the kind is forbidden, so nobody waits for it to sync, the watch keeps retrying
+func (k *trackedKind) onWatchError(r *cache.Reflector, err error) {
+	if k8sErrors.IsForbidden(err) {
+		if !k.forbidden.Swap(true) {
+			log.Warnf("Not allowed to watch %s, showing it as empty: %v", k.gvr, err)
+		}
+		return
+	}
+	cache.DefaultWatchErrorHandler(r, err)
+}
+
+func (t *Tracker) onCRDDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	if crd, ok := obj.(*v1.CustomResourceDefinition); ok {
+		t.untrack(crd.Name)
+	}
+}
+
+func (t *Tracker) untrack(crdName string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if kind, found := t.kinds[crdName]; found {
+		log.Debugf("Stopping to watch %s", kind.gvr)
+		close(kind.stop)
+		delete(t.kinds, crdName)
+	}
+}
+
+func (t *Tracker) stopAll() {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for name, kind := range t.kinds {
+		close(kind.stop)
+		delete(t.kinds, name)
+	}
+}
+
+// Classify detects the role of CRD by its owner, the same way Crossplane itself links CRDs to packages and XRDs
+func Classify(crd *v1.CustomResourceDefinition) Class {
+	switch {
+	case crd.Spec.Group == cpv1.Group && crd.Spec.Names.Kind == cpv1.ProviderKind:
+		return ClassProvider
+	case crd.Spec.Group == cpext.Group && crd.Spec.Names.Kind == cpext.CompositeResourceDefinitionKind:
+		return ClassXRD
+	case crd.Spec.Group == cpext.Group && crd.Spec.Names.Kind == cpext.CompositionKind:
+		return ClassComposition
+	}
+
+	for _, ref := range crd.OwnerReferences {
+		group := strings.SplitN(ref.APIVersion, "/", 2)[0]
+
+		switch {
+		case group == cpext.Group && ref.Kind == cpext.CompositeResourceDefinitionKind:
+			for _, cat := range crd.Spec.Names.Categories {
+				if cat == categoryClaim {
+					return ClassClaim
+				}
+			}
+			return ClassComposite
+		case group == cpv1.Group && (ref.Kind == cpv1.ProviderKind || ref.Kind == cpv1.ProviderRevisionKind):
+			if crd.Spec.Names.Kind == cpk8s.ProviderConfigKind || crd.Spec.Names.Kind == cpk8s.ProviderConfigUsageKind {
+				return ClassNone
+			}
+			return ClassManaged
+		}
+	}
+
+	// CRDs made from API discovery have no owners, only categories
+	for _, cat := range crd.Spec.Names.Categories {
+		switch cat {
+		case categoryClaim:
+			return ClassClaim
+		case categoryComposite:
+			return ClassComposite
+		case categoryManaged:
+			return ClassManaged
+		}
+	}
+	return ClassNone
+}
+
+// servedVersion prefers the storage version, as long as it is served
+func servedVersion(crd *v1.CustomResourceDefinition) string {
+	res := ""
+	for _, ver := range crd.Spec.Versions {
+		if !ver.Served {
+			continue
+		}
+
+		if ver.Storage {
+			return ver.Name
+		}
+
+		if res == "" {
+			res = ver.Name
+		}
+	}
+	return res
+}
+
+func isEstablished(crd *v1.CustomResourceDefinition) bool {
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == v1.Established {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// stripManagedFields saves memory, as we never display managed fields from the cached objects
+func stripManagedFields(obj interface{}) (interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.SetManagedFields(nil)
+	}
+	return obj, nil
+}
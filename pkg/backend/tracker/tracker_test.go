@@ -0,0 +1,263 @@
+package tracker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	crdfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testCRD(group, kind, plural string, owner metav1.OwnerReference, categories ...string) *v1.CustomResourceDefinition {
+	return &v1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            plural + "." + group,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: v1.CustomResourceDefinitionSpec{
+			Group: group,
+			Names: v1.CustomResourceDefinitionNames{Kind: kind, Plural: plural, Categories: categories},
+			Versions: []v1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1beta1", Served: true, Storage: true},
+			},
+		},
+		Status: v1.CustomResourceDefinitionStatus{
+			Conditions: []v1.CustomResourceDefinitionCondition{{Type: v1.Established, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+var (
+	providerOwner = metav1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "Provider", Name: "provider-aws"}
+	revisionOwner = metav1.OwnerReference{APIVersion: "pkg.crossplane.io/v1", Kind: "ProviderRevision", Name: "provider-aws-abc"}
+	xrdOwner      = metav1.OwnerReference{APIVersion: "apiextensions.crossplane.io/v1", Kind: "CompositeResourceDefinition", Name: "xbuckets.example.org"}
+	otherOwner    = metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "something"}
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		crd  *v1.CustomResourceDefinition
+		out  Class
+	}{
+		{"provider-owned", testCRD("s3.aws.upbound.io", "Bucket", "buckets", providerOwner), ClassManaged},
+		{"revision-owned", testCRD("s3.aws.upbound.io", "Bucket", "buckets", revisionOwner), ClassManaged},
+		{"provider config", testCRD("aws.upbound.io", "ProviderConfig", "providerconfigs", providerOwner), ClassNone},
+		{"provider config usage", testCRD("aws.upbound.io", "ProviderConfigUsage", "providerconfigusages", providerOwner), ClassNone},
+		{"composite", testCRD("example.org", "XBucket", "xbuckets", xrdOwner, "crossplane", "composite"), ClassComposite},
+		{"claim", testCRD("example.org", "Bucket", "buckets", xrdOwner, "crossplane", "claim"), ClassClaim},
+		{"unrelated", testCRD("example.org", "Thing", "things", otherOwner), ClassNone},
+		{"providers", testCRD("pkg.crossplane.io", "Provider", "providers", otherOwner), ClassProvider},
+		{"XRDs", testCRD("apiextensions.crossplane.io", "CompositeResourceDefinition", "compositeresourcedefinitions", otherOwner), ClassXRD},
+		{"compositions", testCRD("apiextensions.crossplane.io", "Composition", "compositions", otherOwner), ClassComposition},
+		{"discovered claim", testCRD("example.org", "Bucket", "buckets", metav1.OwnerReference{}, "crossplane", "claim"), ClassClaim},
+		{"discovered managed", testCRD("s3.aws.upbound.io", "Bucket", "buckets", metav1.OwnerReference{}, "crossplane", "managed", "aws"), ClassManaged},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.out, Classify(test.crd))
+		})
+	}
+}
+
+func TestServedVersion(t *testing.T) {
+	crd := testCRD("example.org", "Thing", "things", otherOwner)
+	assert.Equal(t, "v1beta1", servedVersion(crd))
+
+	crd.Spec.Versions[1].Served = false
+	assert.Equal(t, "v1alpha1", servedVersion(crd))
+
+	crd.Spec.Versions[0].Served = false
+	assert.Equal(t, "", servedVersion(crd))
+}
+
+func TestTracker_List(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crdClient := crdfake.NewSimpleClientset(
+		testCRD("s3.aws.upbound.io", "Bucket", "buckets", providerOwner),
+		testCRD("example.org", "XBucket", "xbuckets", xrdOwner, "composite"),
+	)
+
+	bucket := &unstructured.Unstructured{}
+	bucket.SetAPIVersion("s3.aws.upbound.io/v1beta1")
+	bucket.SetKind("Bucket")
+	bucket.SetName("my-bucket")
+	bucket.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "test"}})
+
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}: "BucketList",
+		{Group: "example.org", Version: "v1beta1", Resource: "xbuckets"}:      "XBucketList",
+	}, bucket)
+
+	trk := newTracker(ctx, crdClient, dyn, "", 5*time.Second)
+	changes := atomic.Int32{}
+	trk.OnCRDsChanged(func() { changes.Add(1) })
+	trk.Start()
+
+	assert.Len(t, trk.CRDs(ctx), 2)
+	assert.Equal(t, int32(2), changes.Load())
+
+	managed := trk.List(ctx, ClassManaged)
+	assert.Len(t, managed, 1)
+	assert.Equal(t, "my-bucket", managed[0].GetName())
+	assert.Empty(t, managed[0].GetManagedFields())
+	assert.Empty(t, trk.Unsynced(ClassManaged))
+
+	assert.Empty(t, trk.List(ctx, ClassComposite))
+	assert.Empty(t, trk.List(ctx, ClassClaim))
+
+	gvr, found := trk.ResourceFor(schema.GroupKind{Group: "s3.aws.upbound.io", Kind: "Bucket"})
+	assert.True(t, found)
+	assert.Equal(t, schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}, gvr)
+
+	assert.Equal(t, ClassManaged, trk.ClassOf(schema.GroupKind{Group: "s3.aws.upbound.io", Kind: "Bucket"}))
+	assert.Equal(t, ClassComposite, trk.ClassOf(schema.GroupKind{Group: "example.org", Kind: "XBucket"}))
+	assert.Equal(t, ClassNone, trk.ClassOf(schema.GroupKind{Kind: "Secret"}))
+}
+
+func TestTracker_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crdClient := crdfake.NewSimpleClientset(testCRD("s3.aws.upbound.io", "Bucket", "buckets", providerOwner))
+
+	bucket := &unstructured.Unstructured{}
+	bucket.SetAPIVersion("s3.aws.upbound.io/v1beta1")
+	bucket.SetKind("Bucket")
+	bucket.SetName("my-bucket")
+
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}: "BucketList",
+	}, bucket)
+
+	trk := newTracker(ctx, crdClient, dyn, "", 5*time.Second)
+	events, unsubscribe := trk.Subscribe(10)
+	trk.Start()
+
+	select {
+	case evt := <-events:
+		assert.Equal(t, Event{Type: EventAdded, Class: ClassManaged, APIVersion: "s3.aws.upbound.io/v1beta1", Kind: "Bucket", Name: "my-bucket"}, evt)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive event")
+	}
+
+	unsubscribe()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestTracker_Namespaced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crdClient := crdfake.NewSimpleClientset()
+	crdClient.PrependReactor("list", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(action.GetResource().GroupResource(), "", nil)
+	})
+	crdClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "example.org/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "buckets", Kind: "Bucket", Namespaced: true, Categories: []string{"crossplane", "claim"}},
+				{Name: "buckets/status", Kind: "Bucket", Namespaced: true},
+			},
+		},
+		{
+			GroupVersion: "s3.aws.upbound.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "buckets", Kind: "Bucket", Categories: []string{"crossplane", "managed", "aws"}},
+			},
+		},
+	}
+
+	claim := func(ns string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("example.org/v1")
+		obj.SetKind("Bucket")
+		obj.SetNamespace(ns)
+		obj.SetName("bucket-" + ns)
+		return obj
+	}
+
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "example.org", Version: "v1", Resource: "buckets"}:            "BucketList",
+		{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}: "BucketList",
+	}, claim("team-a"), claim("team-b"))
+	dyn.PrependReactor("list", "buckets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "" {
+			return true, nil, errors.NewForbidden(action.GetResource().GroupResource(), "", nil)
+		}
+		return false, nil, nil
+	})
+
+	trk := newTracker(ctx, crdClient, dyn, "team-a", 5*time.Second)
+	trk.Start()
+
+	assert.Len(t, trk.CRDs(ctx), 2)
+
+	claims := trk.List(ctx, ClassClaim)
+	require.Len(t, claims, 1)
+	assert.Equal(t, "bucket-team-a", claims[0].GetName())
+
+	start := time.Now()
+	assert.Empty(t, trk.List(ctx, ClassManaged))
+	assert.Less(t, time.Since(start), 5*time.Second, "forbidden kinds should not be waited for")
+}
+
+func TestTracker_OnCRDsChanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crd := testCRD("s3.aws.upbound.io", "Bucket", "buckets", providerOwner)
+	crd.Status.Conditions = nil // just created, not served yet
+	crdClient := crdfake.NewSimpleClientset(crd)
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}: "BucketList",
+	})
+
+	trk := newTracker(ctx, crdClient, dyn, "", 5*time.Second)
+	changes := make(chan struct{}, 10)
+	trk.OnCRDsChanged(func() { changes <- struct{}{} })
+	trk.Start()
+	<-changes // added
+
+	update := func(mutate func(crd *v1.CustomResourceDefinition)) {
+		mutate(crd)
+		crd.ResourceVersion += "1"
+		_, err := crdClient.ApiextensionsV1().CustomResourceDefinitions().Update(ctx, crd, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	update(func(crd *v1.CustomResourceDefinition) {
+		crd.Status.Conditions = []v1.CustomResourceDefinitionCondition{{Type: v1.NamesAccepted, Status: v1.ConditionTrue}}
+	})
+	update(func(crd *v1.CustomResourceDefinition) {
+		crd.Status.Conditions = append(crd.Status.Conditions, v1.CustomResourceDefinitionCondition{Type: v1.Established, Status: v1.ConditionTrue})
+	})
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("establishing the CRD is not reported")
+	}
+
+	select {
+	case <-changes:
+		t.Fatal("unrelated status update is reported")
+	case <-time.After(100 * time.Millisecond):
+	}
+}